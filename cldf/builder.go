@@ -0,0 +1,433 @@
+package cldf
+
+import (
+	"errors"
+	"fmt"
+	"gocldf/cldf/ontology"
+	"slices"
+	"strings"
+)
+
+// defaultColumn is a column of a CLDF component as created by default, i.e. a column name together
+// with the CLDF property it implements.
+type defaultColumn struct {
+	name     string
+	property string
+}
+
+// componentDefaults specifies the default file names and columns of CLDF components, following pycldf.
+var componentDefaults = map[string]struct {
+	url     string
+	columns []defaultColumn
+}{
+	"LanguageTable": {"languages.csv", []defaultColumn{
+		{"ID", "id"},
+		{"Name", "name"},
+		{"Macroarea", "macroarea"},
+		{"Latitude", "latitude"},
+		{"Longitude", "longitude"},
+		{"Glottocode", "glottocode"},
+		{"ISO639P3code", "iso639P3code"},
+	}},
+	"ParameterTable": {"parameters.csv", []defaultColumn{
+		{"ID", "id"},
+		{"Name", "name"},
+		{"Description", "description"},
+	}},
+	"CodeTable": {"codes.csv", []defaultColumn{
+		{"ID", "id"},
+		{"Parameter_ID", "parameterReference"},
+		{"Name", "name"},
+		{"Description", "description"},
+	}},
+	"ValueTable": {"values.csv", []defaultColumn{
+		{"ID", "id"},
+		{"Language_ID", "languageReference"},
+		{"Parameter_ID", "parameterReference"},
+		{"Value", "value"},
+		{"Code_ID", "codeReference"},
+		{"Comment", "comment"},
+		{"Source", "source"},
+	}},
+	"FormTable": {"forms.csv", []defaultColumn{
+		{"ID", "id"},
+		{"Language_ID", "languageReference"},
+		{"Parameter_ID", "parameterReference"},
+		{"Form", "form"},
+		{"Segments", "segments"},
+		{"Comment", "comment"},
+		{"Source", "source"},
+	}},
+	"CognateTable": {"cognates.csv", []defaultColumn{
+		{"ID", "id"},
+		{"Form_ID", "formReference"},
+		{"Cognateset_ID", "cognatesetReference"},
+		{"Source", "source"},
+	}},
+	"CognatesetTable": {"cognatesets.csv", []defaultColumn{
+		{"ID", "id"},
+		{"Description", "description"},
+		{"Source", "source"},
+	}},
+	"BorrowingTable": {"borrowings.csv", []defaultColumn{
+		{"ID", "id"},
+		{"Target_Form_ID", "targetFormReference"},
+		{"Source_Form_ID", "sourceFormReference"},
+		{"Comment", "comment"},
+		{"Source", "source"},
+	}},
+	"ExampleTable": {"examples.csv", []defaultColumn{
+		{"ID", "id"},
+		{"Language_ID", "languageReference"},
+		{"Primary_Text", "primaryText"},
+		{"Analyzed_Word", "analyzedWord"},
+		{"Gloss", "gloss"},
+		{"Translated_Text", "translatedText"},
+		{"Meta_Language_ID", "metaLanguageReference"},
+		{"Comment", "comment"},
+	}},
+	"EntryTable": {"entries.csv", []defaultColumn{
+		{"ID", "id"},
+		{"Language_ID", "languageReference"},
+		{"Headword", "headword"},
+		{"Part_Of_Speech", "partOfSpeech"},
+	}},
+	"SenseTable": {"senses.csv", []defaultColumn{
+		{"ID", "id"},
+		{"Description", "description"},
+		{"Entry_ID", "entryReference"},
+	}},
+	"ContributionTable": {"contributions.csv", []defaultColumn{
+		{"ID", "id"},
+		{"Name", "name"},
+		{"Description", "description"},
+		{"Contributor", "contributor"},
+		{"Citation", "citation"},
+	}},
+	"MediaTable": {"media.csv", []defaultColumn{
+		{"ID", "id"},
+		{"Name", "name"},
+		{"Description", "description"},
+		{"Media_Type", "mediaType"},
+		{"Download_URL", "downloadUrl"},
+	}},
+	"TreeTable": {"trees.csv", []defaultColumn{
+		{"ID", "id"},
+		{"Name", "name"},
+		{"Description", "description"},
+		{"Tree_Is_Rooted", "treeIsRooted"},
+		{"Tree_Type", "treeType"},
+		{"Tree_Branch_Length_Unit", "treeBranchLengthUnit"},
+		{"Media_ID", "mediaReference"},
+		{"Source", "source"},
+	}},
+}
+
+// referencedComponents maps reference properties to the components they reference, where this
+// cannot be derived from the property name.
+var referencedComponents = map[string]string{
+	"metaLanguageReference": "LanguageTable",
+	"targetFormReference":   "FormTable",
+	"sourceFormReference":   "FormTable",
+}
+
+// propertyColumn returns the JSON description of a column implementing a CLDF property.
+func propertyColumn(name string, property string) map[string]any {
+	col := map[string]any{"name": name, "propertyUrl": ontology.Namespace() + property}
+	if sep, ok := listSeparators[property]; ok {
+		col["separator"] = sep
+	}
+	switch property {
+	case "id":
+		col["required"] = true
+		col["datatype"] = map[string]any{"base": "string", "format": "[a-zA-Z0-9_\\-]+"}
+	case "latitude":
+		col["datatype"] = map[string]any{"base": "decimal", "minimum": -90.0, "maximum": 90.0}
+	case "longitude":
+		col["datatype"] = map[string]any{"base": "decimal", "minimum": -180.0, "maximum": 180.0}
+	case "treeIsRooted":
+		col["datatype"] = "boolean"
+	}
+	return col
+}
+
+/*
+Builder creates CLDF datasets programmatically.
+
+Tables are added either as CLDF components with their default columns or as custom tables.
+Rows are added as cell values formatted as strings, and are validated and converted into Go
+objects just like rows read from a CSV file. Foreign keys for reference properties (e.g.
+languageReference) are added when the dataset is built.
+
+Usage:
+
+	b, err := cldf.NewBuilder("StructureDataset")
+	_, err = b.AddComponent("LanguageTable")
+	err = b.AddRow("LanguageTable", map[string]string{"ID": "l1", "Name": "Language 1"})
+	ds, err := b.Dataset()
+	err = ds.Write(dir)
+*/
+type Builder struct {
+	dataset *Dataset
+	sources []*Source
+}
+
+// NewBuilder starts a dataset of the given CLDF module.
+func NewBuilder(module string) (*Builder, error) {
+	if !ontology.IsModule(module) {
+		return nil, fmt.Errorf("unknown CLDF module %v", module)
+	}
+	dialect, err := NewDialect(map[string]any{})
+	if err != nil {
+		return nil, err
+	}
+	metadata := map[string]any{
+		"@context":      "http://www.w3.org/ns/csvw",
+		"dc:conformsTo": ontology.Namespace() + module,
+	}
+	return &Builder{dataset: &Dataset{
+		MetadataPath: module + "-metadata.json",
+		Metadata:     metadata,
+		Dialect:      dialect,
+		Tables:       make(map[string]*Table),
+		Module:       module,
+	}}, nil
+}
+
+// SetMetadata sets a property of the dataset's metadata, e.g. dc:title.
+func (b *Builder) SetMetadata(key string, value any) {
+	b.dataset.Metadata[key] = value
+}
+
+// AddComponent adds a CLDF component with its default columns to the dataset.
+func (b *Builder) AddComponent(component string) (*Table, error) {
+	if !ontology.IsComponent(component) {
+		return nil, fmt.Errorf("unknown CLDF component %v", component)
+	}
+	var columns []map[string]any
+	defaults, ok := componentDefaults[component]
+	if ok {
+		for _, col := range defaults.columns {
+			columns = append(columns, propertyColumn(col.name, col.property))
+		}
+	} else {
+		// Components without defaults get columns for their required properties.
+		defaults.url = strings.ToLower(strings.TrimSuffix(component, "Table")) + "s.csv"
+		for _, prop := range ontology.RequiredProperties(component) {
+			columns = append(columns, propertyColumn(columnName(prop), prop))
+		}
+	}
+	return b.addTable(defaults.url, ontology.Namespace()+component, columns)
+}
+
+// AddTable adds a custom table - i.e. a table which is not a CLDF component - to the dataset.
+// The columns are specified as JSON descriptions as in the metadata.
+func (b *Builder) AddTable(url string, columns ...map[string]any) (*Table, error) {
+	return b.addTable(url, "", columns)
+}
+
+func (b *Builder) addTable(url string, conformsTo string, columns []map[string]any) (*Table, error) {
+	jsonCols := make([]any, len(columns))
+	var pk []any
+	for i, col := range columns {
+		jsonCols[i] = col
+		if col["propertyUrl"] == ontology.Namespace()+"id" {
+			pk = append(pk, col["name"])
+		}
+	}
+	jsonTable := map[string]any{"url": url, "tableSchema": map[string]any{"columns": jsonCols}}
+	if len(pk) > 0 {
+		jsonTable["tableSchema"].(map[string]any)["primaryKey"] = pk
+	}
+	if conformsTo != "" {
+		jsonTable["dc:conformsTo"] = conformsTo
+	}
+	tbl, err := NewTable(jsonTable, false)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := b.dataset.Tables[tbl.CanonicalName]; ok {
+		return nil, fmt.Errorf("table %v already exists", tbl.CanonicalName)
+	}
+	for _, other := range b.dataset.Tables {
+		if other.Url == tbl.Url {
+			return nil, fmt.Errorf("table with url %v already exists", tbl.Url)
+		}
+	}
+	b.dataset.Tables[tbl.CanonicalName] = tbl
+	b.dataset.order = append(b.dataset.order, tbl.CanonicalName)
+	return tbl, nil
+}
+
+// AddColumn adds a column - specified as JSON description as in the metadata, e.g. including a
+// datatype - to a table without rows.
+func (b *Builder) AddColumn(table string, jsonCol map[string]any) (*Column, error) {
+	tbl, err := b.table(table)
+	if err != nil {
+		return nil, err
+	}
+	if len(tbl.Data) > 0 {
+		return nil, fmt.Errorf("cannot add column to table %v with rows", table)
+	}
+	col, err := NewColumn(len(tbl.Columns), jsonCol)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := tbl.nameToCol()[col.Name]; ok {
+		return nil, fmt.Errorf("column %v already exists in table %v", col.Name, table)
+	}
+	tbl.Columns = append(tbl.Columns, col)
+	schema := tbl.metadata["tableSchema"].(map[string]any)
+	schema["columns"] = append(schema["columns"].([]any), jsonCol)
+	return col, nil
+}
+
+// AddRow adds a row to a table. Cell values are given as strings keyed by column name; missing
+// cells are empty. Values are validated and converted using Column.ToGo. Invalid rows are
+// rejected, returning all validation errors of the row.
+func (b *Builder) AddRow(table string, row map[string]string) error {
+	tbl, err := b.table(table)
+	if err != nil {
+		return err
+	}
+	fields := make([]string, len(tbl.Columns))
+	for name, val := range row {
+		i := slices.IndexFunc(tbl.Columns, func(col *Column) bool { return col.Name == name })
+		if i < 0 {
+			return fmt.Errorf("unknown column %v in table %v", name, table)
+		}
+		fields[i] = val
+	}
+	number := len(tbl.Data) + 1
+	data, errs := tbl.readRow(fields, number, false)
+	if len(errs) > 0 {
+		return joinValidationErrors(errs)
+	}
+	if verr := tbl.indexRow(data, number, len(tbl.Data)); verr != nil {
+		return verr
+	}
+	tbl.Data = append(tbl.Data, data)
+	tbl.resetIndexes()
+	return nil
+}
+
+// AddSource adds a source to the dataset's sources.
+func (b *Builder) AddSource(src *Source) {
+	b.sources = append(b.sources, src)
+}
+
+// Dataset returns the dataset built so far, with foreign keys for reference properties. Dangling
+// references - including references to unknown sources - are reported as error.
+func (b *Builder) Dataset() (*Dataset, error) {
+	ds := b.dataset
+	if len(b.sources) > 0 {
+		ds.Metadata["dc:source"] = "sources.bib"
+		ds.Sources = &Sources{Path: "sources.bib", Items: b.sources}
+		for _, src := range b.sources {
+			for name := range src.Fields {
+				if !slices.Contains(ds.Sources.FieldNames, name) {
+					ds.Sources.FieldNames = append(ds.Sources.FieldNames, name)
+				}
+			}
+		}
+	}
+	for name, tbl := range ds.Tables {
+		b.addForeignKeys(tbl)
+		// The table is re-created from its metadata to pick up foreign keys.
+		rebuilt, err := NewTable(tbl.metadata, ds.Sources != nil)
+		if err != nil {
+			return nil, err
+		}
+		rebuilt.Data, rebuilt.pkIndex = tbl.Data, tbl.pkIndex
+		ds.Tables[name] = rebuilt
+	}
+	if errs := ds.CheckForeignKeys(); len(errs) > 0 {
+		return nil, joinValidationErrors(errs)
+	}
+	return ds, nil
+}
+
+// addForeignKeys adds foreign keys for columns implementing reference properties to the
+// metadata of tbl, if the referenced component is part of the dataset.
+func (b *Builder) addForeignKeys(tbl *Table) {
+	schema := tbl.metadata["tableSchema"].(map[string]any)
+	fks, _ := schema["foreignKeys"].([]any)
+	for _, col := range tbl.Columns {
+		prop, ok := ontology.Term(col.PropertyUrl.String())
+		if !ok || prop == "source" || !strings.HasSuffix(prop, "Reference") {
+			continue
+		}
+		if slices.ContainsFunc(tbl.ForeignKeys, func(fk *ForeignKey) bool {
+			return slices.Equal(fk.ColumnReference, []string{col.Name})
+		}) {
+			continue
+		}
+		component, ok := referencedComponents[prop]
+		if !ok {
+			component = strings.ToUpper(prop[:1]) + strings.TrimSuffix(prop[1:], "Reference") + "Table"
+		}
+		target, ok := b.dataset.Tables[component]
+		if !ok {
+			continue
+		}
+		idCol := slices.IndexFunc(target.Columns, func(c *Column) bool { return c.CanonicalName == "cldf_id" })
+		if idCol < 0 {
+			continue
+		}
+		fks = append(fks, map[string]any{
+			"columnReference": []any{col.Name},
+			"reference": map[string]any{
+				"resource":        target.Url,
+				"columnReference": []any{target.Columns[idCol].Name},
+			},
+		})
+	}
+	if len(fks) > 0 {
+		schema["foreignKeys"] = fks
+	}
+}
+
+func (b *Builder) table(name string) (*Table, error) {
+	if tbl, ok := b.dataset.Tables[name]; ok {
+		return tbl, nil
+	}
+	for _, tbl := range b.dataset.Tables {
+		if tbl.Url == name {
+			return tbl, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown table %v", name)
+}
+
+// columnName derives a column name from a CLDF property name, following the naming conventions of
+// pycldf, e.g. "Language_ID" for languageReference. It is the inverse of propertyName.
+func columnName(property string) string {
+	if property == "id" {
+		return "ID"
+	}
+	suffix := ""
+	if before, ok := strings.CutSuffix(property, "Reference"); ok {
+		property, suffix = before, "_ID"
+	}
+	var words []string
+	start := 0
+	for i, r := range property {
+		if i > 0 && r >= 'A' && r <= 'Z' {
+			words = append(words, property[start:i])
+			start = i
+		}
+	}
+	words = append(words, property[start:])
+	for i, word := range words {
+		words[i] = strings.ToUpper(word[:1]) + word[1:]
+	}
+	return strings.Join(words, "_") + suffix
+}
+
+func joinValidationErrors(verrs []*ValidationError) error {
+	errs := make([]error, len(verrs))
+	for i, verr := range verrs {
+		errs[i] = verr
+	}
+	return errors.Join(errs...)
+}
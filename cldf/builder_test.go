@@ -0,0 +1,103 @@
+package cldf
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	b, err := NewBuilder("StructureDataset")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.SetMetadata("dc:title", "A built dataset")
+	for _, comp := range []string{"LanguageTable", "ParameterTable", "ValueTable"} {
+		if _, err = b.AddComponent(comp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err = b.AddComponent("ValueTable"); err == nil {
+		t.Errorf(`problem: components can only be added once`)
+	}
+	_, err = b.AddColumn("LanguageTable", map[string]any{"name": "Speakers", "datatype": "integer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.AddSource(&Source{Id: "src1", Type: "book", Fields: map[string]string{"title": "The Book"}})
+
+	rows := []struct {
+		table string
+		row   map[string]string
+	}{
+		{"LanguageTable", map[string]string{"ID": "l1", "Name": "Lang 1", "Latitude": "12.5", "Speakers": "100"}},
+		{"LanguageTable", map[string]string{"ID": "l2", "Name": "Lang 2"}},
+		{"ParameterTable", map[string]string{"ID": "p1", "Name": "Param 1"}},
+		{"ValueTable", map[string]string{"ID": "v1", "Language_ID": "l1", "Parameter_ID": "p1", "Value": "x", "Source": "src1[12]"}},
+	}
+	for _, r := range rows {
+		if err = b.AddRow(r.table, r.row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var verr *ValidationError
+	err = b.AddRow("LanguageTable", map[string]string{"ID": "l3", "Latitude": "100"})
+	if !errors.As(err, &verr) || verr.Rule != "maxInclusive" {
+		t.Errorf(`problem: %v`, err)
+	}
+	err = b.AddRow("LanguageTable", map[string]string{"ID": "l1"})
+	if !errors.As(err, &verr) || verr.Rule != "primaryKey" {
+		t.Errorf(`problem: %v`, err)
+	}
+	if err = b.AddRow("LanguageTable", map[string]string{"Unknown": "x"}); err == nil {
+		t.Errorf(`problem: unknown columns must be rejected`)
+	}
+
+	ds, err := b.Dataset()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ds.Tables["ValueTable"].ForeignKeys) != 3 {
+		t.Errorf(`problem: %v`, ds.Tables["ValueTable"].ForeignKeys)
+	}
+	dir := t.TempDir()
+	if err = ds.Write(dir); err != nil {
+		t.Fatal(err)
+	}
+	written, err := NewDataset(filepath.Join(dir, "StructureDataset-metadata.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs, err := written.Validate(0); err != nil || len(errs) > 0 {
+		t.Errorf(`problem: %v %v`, err, errs)
+	}
+	for name, tbl := range ds.Tables {
+		if !reflect.DeepEqual(tbl.Data, written.Tables[name].Data) {
+			t.Errorf(`problem: %v vs %v`, tbl.Data, written.Tables[name].Data)
+		}
+	}
+}
+
+func TestBuilder_danglingReference(t *testing.T) {
+	b, _ := NewBuilder("Wordlist")
+	_, _ = b.AddComponent("LanguageTable")
+	_, _ = b.AddComponent("FormTable")
+	err := b.AddRow("FormTable", map[string]string{"ID": "f1", "Language_ID": "l1", "Form": "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = b.Dataset()
+	var verr *ValidationError
+	if !errors.As(err, &verr) || verr.Rule != "foreignKey" || verr.Value != "l1" {
+		t.Errorf(`problem: %v`, err)
+	}
+}
+
+func Test_columnName(t *testing.T) {
+	for _, prop := range []string{"id", "languageReference", "primaryText", "metaLanguageReference"} {
+		if propertyName(columnName(prop)) != prop {
+			t.Errorf(`problem: %v vs %v`, prop, columnName(prop))
+		}
+	}
+}
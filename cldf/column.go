@@ -1,6 +1,7 @@
 package cldf
 
 import (
+	"errors"
 	"fmt"
 	"gocldf/cldf/datatype"
 	"gocldf/internal/jsonutil"
@@ -9,22 +10,28 @@ import (
 	"strings"
 )
 
+var errRequired = errors.New("required value missing")
+
 type Column struct {
 	Name          string
-	CanonicalName string // Either the CLDF property short name or the column name
-	PropertyUrl   string
+	CanonicalName string       // Either the CLDF property short name or the column name
+	PropertyUrl   *URITemplate // The template for the URL of the property of cells, or nil
 	Datatype      datatype.Datatype
 	Separator     string
 	Null          []string
+	Default       string // The string to use for empty cells
+	Required      bool
+	Lang          string       // The language of string values
+	AboutUrl      *URITemplate // The template for the URL of the subject of cells, or nil
+	ValueUrl      *URITemplate // The template for URLs identifying the values of cells, or nil
+	number        int          // The 1-based position of the column in the table
 }
 
 func NewColumn(index int, jsonCol map[string]interface{}) (*Column, error) {
 	var (
-		err           error
-		name          = ""
-		purl          = ""
-		sep           = ""
-		canonicalName = ""
+		err  error
+		name = ""
+		sep  = ""
 	)
 	name, err = jsonutil.GetString(jsonCol, "name", "Col_"+strconv.Itoa(index+1))
 	if err != nil {
@@ -34,10 +41,6 @@ func NewColumn(index int, jsonCol map[string]interface{}) (*Column, error) {
 	if err != nil {
 		return nil, err
 	}
-	purl, err = jsonutil.GetString(jsonCol, "propertyUrl", "")
-	if err != nil {
-		return nil, err
-	}
 	null, err := jsonutil.GetStringArray(jsonCol, "null")
 	if err != nil {
 		return nil, err
@@ -45,46 +48,150 @@ func NewColumn(index int, jsonCol map[string]interface{}) (*Column, error) {
 	if len(null) == 0 {
 		null = append(null, "")
 	}
-	if purl != "" && strings.HasPrefix(purl, "http://cldf.clld.org") {
-		parts := strings.Split(purl, "#")
-		canonicalName = "cldf_" + parts[len(parts)-1]
-	} else {
-		canonicalName = name
+	dflt, err := jsonutil.GetString(jsonCol, "default", "")
+	if err != nil {
+		return nil, err
+	}
+	required, err := jsonutil.GetBool(jsonCol, "required", false)
+	if err != nil {
+		return nil, err
+	}
+	lang, err := jsonutil.GetString(jsonCol, "lang", "und")
+	if err != nil {
+		return nil, err
 	}
 	dt, err := datatype.New(jsonCol)
 	if err != nil {
 		return nil, err
 	}
+	templates := make(map[string]*URITemplate, 3)
+	for _, key := range []string{"aboutUrl", "propertyUrl", "valueUrl"} {
+		s, err := jsonutil.GetString(jsonCol, key, "")
+		if err != nil {
+			return nil, err
+		}
+		if s != "" {
+			if templates[key], err = ParseURITemplate(s); err != nil {
+				return nil, fmt.Errorf("column %v: %w", name, err)
+			}
+		}
+	}
 	col := &Column{
-		Name:          name,
-		CanonicalName: canonicalName,
-		PropertyUrl:   purl,
-		Datatype:      *dt,
-		Separator:     sep,
-		Null:          null}
+		Name:        name,
+		PropertyUrl: templates["propertyUrl"],
+		Datatype:    *dt,
+		Separator:   sep,
+		Null:        null,
+		Default:     dflt,
+		Required:    required,
+		Lang:        lang,
+		AboutUrl:    templates["aboutUrl"],
+		ValueUrl:    templates["valueUrl"],
+		number:      index + 1}
+	col.CanonicalName = col.canonicalName()
 	return col, nil
 }
 
+// canonicalName returns the name of the CLDF property specified as propertyUrl - prefixed with
+// "cldf_" - or the name of the column. Templates with expressions do not specify a property.
+func (column *Column) canonicalName() string {
+	purl := column.PropertyUrl.String()
+	if strings.HasPrefix(purl, "http://cldf.clld.org") && !strings.Contains(purl, "{") {
+		parts := strings.Split(purl, "#")
+		return "cldf_" + parts[len(parts)-1]
+	}
+	return column.Name
+}
+
+// ExpandAboutUrl returns the URL of the subject of the column's cell in a row - given as template
+// variables, see Table.TemplateVariables - or the empty string if the column has no aboutUrl.
+func (column *Column) ExpandAboutUrl(vars map[string]any) string {
+	if column.AboutUrl == nil {
+		return ""
+	}
+	return column.AboutUrl.Expand(column.templateVariables(vars))
+}
+
+// ExpandPropertyUrl returns the URL of the property of the column's cell in a row, or the empty
+// string if the column has no propertyUrl.
+func (column *Column) ExpandPropertyUrl(vars map[string]any) string {
+	if column.PropertyUrl == nil {
+		return ""
+	}
+	return column.PropertyUrl.Expand(column.templateVariables(vars))
+}
+
+// ExpandValueUrl returns the URL identifying the value of the column's cell in a row, or the
+// empty string if the column has no valueUrl.
+func (column *Column) ExpandValueUrl(vars map[string]any) string {
+	if column.ValueUrl == nil {
+		return ""
+	}
+	return column.ValueUrl.Expand(column.templateVariables(vars))
+}
+
+// templateVariables adds the variables describing the column to the variables of a row.
+func (column *Column) templateVariables(vars map[string]any) map[string]any {
+	res := make(map[string]any, len(vars)+3)
+	for k, v := range vars {
+		res[k] = v
+	}
+	res["_name"] = column.Name
+	res["_column"] = strconv.Itoa(column.number)
+	res["_sourceColumn"] = res["_column"]
+	return res
+}
+
+// ToGo parses the string value of a cell into a Go object, following the cell parsing procedure
+// of the CSVW spec (see package datatype). If split is true, values of list-valued columns are
+// returned as slices of strings, with null items removed.
 func (column *Column) ToGo(s string, split bool, noChecks bool) (any, error) {
-	if slices.Contains(column.Null, s) {
-		// Return an empty list for list-valued fields, nil otherwise.
-		if split && column.Separator != "" {
-			return make([]string, 0), nil
-		}
-		return nil, nil
+	s = column.Datatype.Normalize(s)
+	if s == "" {
+		s = column.Default
 	}
 	if split && column.Separator != "" {
+		if s == "" || slices.Contains(column.Null, s) {
+			if column.Required && !noChecks {
+				return nil, errRequired
+			}
+			// Return an empty list for null values of list-valued fields.
+			return make([]string, 0), nil
+		}
 		fields := strings.Split(s, column.Separator)
-		res := make([]string, len(fields))
-		for i, field := range fields {
-			val, err := column.ToGo(field, false, noChecks)
+		res := make([]string, 0, len(fields))
+		for _, field := range fields {
+			if !column.Datatype.IsString() {
+				field = strings.TrimSpace(field)
+			}
+			if field == "" {
+				field = column.Default
+			}
+			val, err := column.atomicToGo(field, noChecks)
 			if err != nil {
 				return nil, err
 			}
-			res[i] = val.(string)
+			if val != nil {
+				// List items are kept as strings, but must be valid for the datatype.
+				res = append(res, field)
+			}
 		}
 		return res, nil
 	}
+	return column.atomicToGo(s, noChecks)
+}
+
+// atomicToGo implements the steps of the cell parsing procedure for single values.
+func (column *Column) atomicToGo(s string, noChecks bool) (any, error) {
+	if s == "" {
+		s = column.Default
+	}
+	if slices.Contains(column.Null, s) {
+		if column.Separator == "" && column.Required && !noChecks {
+			return nil, errRequired
+		}
+		return nil, nil
+	}
 	return column.Datatype.ToGo(s, noChecks)
 }
 
@@ -99,34 +206,48 @@ func (column *Column) ToString(x any) (string, error) {
 	return column.Datatype.ToString(x)
 }
 
-func (column *Column) sqlCreate(noChecks bool) string {
-	res := fmt.Sprintf("`%v`\t%v", column.CanonicalName, column.Datatype.SqlType())
-	if !noChecks {
-		var checks []string
-		if column.Datatype.MinInclusive != nil {
-			checks = append(checks, fmt.Sprintf("`%v` >= %v", column.CanonicalName, column.Datatype.MinInclusive))
-		}
-		if column.Datatype.MinExclusive != nil {
-			checks = append(checks, fmt.Sprintf("`%v` > %v", column.CanonicalName, column.Datatype.MinExclusive))
-		}
-		if column.Datatype.MaxInclusive != nil {
-			checks = append(checks, fmt.Sprintf("`%v` <= %v", column.CanonicalName, column.Datatype.MaxInclusive))
-		}
-		if column.Datatype.MaxExclusive != nil {
-			checks = append(checks, fmt.Sprintf("`%v` < %v", column.CanonicalName, column.Datatype.MaxExclusive))
-		}
-		if column.Datatype.Length >= 0 {
-			checks = append(checks, fmt.Sprintf("length(`%v`) = %v", column.CanonicalName, column.Datatype.Length))
-		}
-		if column.Datatype.MinLength >= 0 {
-			checks = append(checks, fmt.Sprintf("length(`%v`) >= %v", column.CanonicalName, column.Datatype.MinLength))
-		}
-		if column.Datatype.MaxLength >= 0 {
-			checks = append(checks, fmt.Sprintf("length(`%v`) <= %v", column.CanonicalName, column.Datatype.MaxLength))
-		}
-		if len(checks) > 0 {
-			res += fmt.Sprintf(" CHECK(%v)", strings.Join(checks, " AND "))
+// sqlCreate returns the definition of the column in a CREATE TABLE statement. List-valued columns
+// are stored as strings, joined with the separator, so their type is the type of strings and the
+// constraints of the datatype - which apply to the list items - are not checked.
+func (column *Column) sqlCreate(noChecks bool, d SqlDialect) (string, error) {
+	dt := &column.Datatype
+	name := d.Quote(column.CanonicalName)
+	if column.Separator != "" {
+		return fmt.Sprintf("%v\t%v", name, d.ColumnType(&datatype.Datatype{Base: "string"})), nil
+	}
+	res := fmt.Sprintf("%v\t%v", name, d.ColumnType(dt))
+	if noChecks {
+		return res, nil
+	}
+	var checks []string
+	for _, bound := range []struct {
+		op  string
+		val any
+	}{
+		{">=", dt.MinInclusive},
+		{">", dt.MinExclusive},
+		{"<=", dt.MaxInclusive},
+		{"<", dt.MaxExclusive},
+	} {
+		if bound.val != nil {
+			val, err := d.ToSql(dt, bound.val)
+			if err != nil {
+				return "", err
+			}
+			checks = append(checks, fmt.Sprintf("%v %v %v", name, bound.op, sqlLiteral(val)))
 		}
 	}
-	return res
+	if dt.Length >= 0 {
+		checks = append(checks, fmt.Sprintf("length(%v) = %v", name, dt.Length))
+	}
+	if dt.MinLength >= 0 {
+		checks = append(checks, fmt.Sprintf("length(%v) >= %v", name, dt.MinLength))
+	}
+	if dt.MaxLength >= 0 {
+		checks = append(checks, fmt.Sprintf("length(%v) <= %v", name, dt.MaxLength))
+	}
+	if len(checks) > 0 {
+		res += fmt.Sprintf(" CHECK(%v)", strings.Join(checks, " AND "))
+	}
+	return res, nil
 }
@@ -2,6 +2,7 @@ package cldf
 
 import (
 	"encoding/json"
+	"slices"
 	"testing"
 )
 
@@ -27,6 +28,7 @@ func TestColumn_CanonicalName(t *testing.T) {
 		{`{"name":"The Name"}`, "The Name"},
 		{`{"name":"The Name", "propertyUrl": "http://cldf.clld.org/#prop"}`, "cldf_prop"},
 		{`{}`, "Col_1"},
+		{`{"name":"X", "propertyUrl": "http://cldf.clld.org/v1.0/terms.rdf#{_name}"}`, "X"},
 	}
 	for _, tt := range tests {
 		t.Run("CanonicalName", func(t *testing.T) {
@@ -56,3 +58,62 @@ func TestColumn_Datatype(t *testing.T) {
 		})
 	}
 }
+
+func TestColumn_ToGo(t *testing.T) {
+	var tests = []struct {
+		jsonCol   string
+		input     string
+		assertion func(any) bool
+	}{
+		{`{"datatype": "integer"}`, " 5\n", func(x any) bool { return x.(int) == 5 }},
+		{`{"datatype": "string"}`, " 5\n", func(x any) bool { return x.(string) == " 5\n" }},
+		{`{"default": "x"}`, "", func(x any) bool { return x.(string) == "x" }},
+		{`{"null": ["x"], "default": "x"}`, "", func(x any) bool { return x == nil }},
+		{`{"datatype": "integer", "separator": ";"}`, "1; 2 ;;3", func(x any) bool {
+			return slices.Equal(x.([]string), []string{"1", "2", "3"})
+		}},
+		{`{"separator": ";"}`, "a; b", func(x any) bool {
+			return slices.Equal(x.([]string), []string{"a", " b"})
+		}},
+		{`{"datatype": "json", "separator": ";"}`, "1; 2", func(x any) bool {
+			return slices.Equal(x.([]string), []string{"1", " 2"})
+		}},
+		{`{"separator": ";", "null": ["NA"]}`, "NA", func(x any) bool {
+			return len(x.([]string)) == 0
+		}},
+	}
+	for _, tt := range tests {
+		t.Run("ToGo", func(t *testing.T) {
+			col := makeCol(tt.jsonCol)
+			val, err := col.ToGo(tt.input, true, false)
+			if err != nil || !tt.assertion(val) {
+				t.Errorf(`problem: %q vs %v (%v)`, tt.input, val, err)
+			}
+		})
+	}
+}
+
+func TestColumn_ToGoError(t *testing.T) {
+	var tests = []struct {
+		jsonCol string
+		input   string
+	}{
+		{`{"required": true}`, ""},
+		{`{"required": true, "null": ["?"]}`, "?"},
+		{`{"required": true, "separator": ";"}`, ""},
+		{`{"required": true, "separator": ";", "null": ["NA"]}`, "NA"},
+		{`{"datatype": "integer", "separator": ";"}`, "1;x"},
+	}
+	for _, tt := range tests {
+		t.Run("ToGo", func(t *testing.T) {
+			col := makeCol(tt.jsonCol)
+			if val, err := col.ToGo(tt.input, true, false); err == nil {
+				t.Errorf(`problem: %q vs %v`, tt.input, val)
+			}
+		})
+	}
+	col := makeCol(`{"required": true}`)
+	if _, err := col.ToGo("", true, true); err != nil {
+		t.Errorf(`problem: required must not be checked with noChecks`)
+	}
+}
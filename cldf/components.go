@@ -0,0 +1,437 @@
+package cldf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Object is the common part of the typed objects for rows of CLDF components.
+type Object struct {
+	ID string
+	// Extra holds the values of non-standard columns - i.e. columns without a CLDF property -
+	// keyed by column name.
+	Extra   map[string]any
+	row     map[string]any
+	dataset *Dataset
+}
+
+// Row returns the underlying row, keyed by canonical column names.
+func (o *Object) Row() map[string]any {
+	return o.row
+}
+
+func newObject(dataset *Dataset, row map[string]any) Object {
+	extra := make(map[string]any)
+	for k, v := range row {
+		if !strings.HasPrefix(k, "cldf_") {
+			extra[k] = v
+		}
+	}
+	return Object{ID: stringValue(row, "cldf_id"), Extra: extra, row: row, dataset: dataset}
+}
+
+// stringValue returns the value of a column formatted as string, or "" for null values.
+func stringValue(row map[string]any, key string) string {
+	switch val := row[key].(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+func floatValue(row map[string]any, key string) *float64 {
+	if val, ok := row[key].(float64); ok {
+		return &val
+	}
+	return nil
+}
+
+func listValue(row map[string]any, key string) []string {
+	val, _ := row[key].([]string)
+	return val
+}
+
+func sourceReferences(row map[string]any) []SourceReference {
+	val, _ := row["cldf_source"].([]SourceReference)
+	return val
+}
+
+// sources resolves source references of a row.
+func (o *Object) sources() []*Source {
+	var res []*Source
+	if o.dataset.Sources == nil {
+		return res
+	}
+	for _, ref := range sourceReferences(o.row) {
+		if src, ok := o.dataset.Sources.Get(ref.Key); ok {
+			res = append(res, src)
+		}
+	}
+	return res
+}
+
+type Language struct {
+	Object
+	Name       string
+	Macroarea  string
+	Glottocode string
+	ISO639P3   string
+	Latitude   *float64
+	Longitude  *float64
+}
+
+type Parameter struct {
+	Object
+	Name        string
+	Description string
+}
+
+type Code struct {
+	Object
+	ParameterID string
+	Name        string
+	Description string
+}
+
+type Value struct {
+	Object
+	LanguageID       string
+	ParameterID      string
+	CodeID           string
+	Value            string
+	Comment          string
+	SourceReferences []SourceReference
+}
+
+type Form struct {
+	Object
+	LanguageID       string
+	ParameterID      string
+	Form             string
+	Segments         []string
+	Comment          string
+	SourceReferences []SourceReference
+}
+
+type Cognate struct {
+	Object
+	FormID           string
+	CognatesetID     string
+	SourceReferences []SourceReference
+}
+
+type Example struct {
+	Object
+	LanguageID     string
+	PrimaryText    string
+	AnalyzedWord   []string
+	Gloss          []string
+	TranslatedText string
+	MetaLanguageID string
+	Comment        string
+}
+
+func newLanguage(dataset *Dataset, row map[string]any) *Language {
+	return &Language{
+		Object:     newObject(dataset, row),
+		Name:       stringValue(row, "cldf_name"),
+		Macroarea:  stringValue(row, "cldf_macroarea"),
+		Glottocode: stringValue(row, "cldf_glottocode"),
+		ISO639P3:   stringValue(row, "cldf_iso639P3code"),
+		Latitude:   floatValue(row, "cldf_latitude"),
+		Longitude:  floatValue(row, "cldf_longitude"),
+	}
+}
+
+func newParameter(dataset *Dataset, row map[string]any) *Parameter {
+	return &Parameter{
+		Object:      newObject(dataset, row),
+		Name:        stringValue(row, "cldf_name"),
+		Description: stringValue(row, "cldf_description"),
+	}
+}
+
+func newCode(dataset *Dataset, row map[string]any) *Code {
+	return &Code{
+		Object:      newObject(dataset, row),
+		ParameterID: stringValue(row, "cldf_parameterReference"),
+		Name:        stringValue(row, "cldf_name"),
+		Description: stringValue(row, "cldf_description"),
+	}
+}
+
+func newValue(dataset *Dataset, row map[string]any) *Value {
+	return &Value{
+		Object:           newObject(dataset, row),
+		LanguageID:       stringValue(row, "cldf_languageReference"),
+		ParameterID:      stringValue(row, "cldf_parameterReference"),
+		CodeID:           stringValue(row, "cldf_codeReference"),
+		Value:            stringValue(row, "cldf_value"),
+		Comment:          stringValue(row, "cldf_comment"),
+		SourceReferences: sourceReferences(row),
+	}
+}
+
+func newForm(dataset *Dataset, row map[string]any) *Form {
+	return &Form{
+		Object:           newObject(dataset, row),
+		LanguageID:       stringValue(row, "cldf_languageReference"),
+		ParameterID:      stringValue(row, "cldf_parameterReference"),
+		Form:             stringValue(row, "cldf_form"),
+		Segments:         listValue(row, "cldf_segments"),
+		Comment:          stringValue(row, "cldf_comment"),
+		SourceReferences: sourceReferences(row),
+	}
+}
+
+func newCognate(dataset *Dataset, row map[string]any) *Cognate {
+	return &Cognate{
+		Object:           newObject(dataset, row),
+		FormID:           stringValue(row, "cldf_formReference"),
+		CognatesetID:     stringValue(row, "cldf_cognatesetReference"),
+		SourceReferences: sourceReferences(row),
+	}
+}
+
+func newExample(dataset *Dataset, row map[string]any) *Example {
+	return &Example{
+		Object:         newObject(dataset, row),
+		LanguageID:     stringValue(row, "cldf_languageReference"),
+		PrimaryText:    stringValue(row, "cldf_primaryText"),
+		AnalyzedWord:   listValue(row, "cldf_analyzedWord"),
+		Gloss:          listValue(row, "cldf_gloss"),
+		TranslatedText: stringValue(row, "cldf_translatedText"),
+		MetaLanguageID: stringValue(row, "cldf_metaLanguageReference"),
+		Comment:        stringValue(row, "cldf_comment"),
+	}
+}
+
+// objects materializes all rows of a component.
+func objects[T any](dataset *Dataset, component string, newT func(*Dataset, map[string]any) T) []T {
+	tbl, ok := dataset.Tables[component]
+	if !ok {
+		return nil
+	}
+	res := make([]T, len(tbl.Data))
+	for i, row := range tbl.Data {
+		res[i] = newT(dataset, row)
+	}
+	return res
+}
+
+// object materializes the row of a component with the given ID.
+func object[T any](dataset *Dataset, component string, id string, newT func(*Dataset, map[string]any) T) (T, bool) {
+	var zero T
+	if id == "" {
+		return zero, false
+	}
+	row, ok := dataset.Get(component, id)
+	if !ok {
+		return zero, false
+	}
+	return newT(dataset, row), true
+}
+
+// referencing materializes the rows of a component referencing id in the given column.
+func referencing[T any](dataset *Dataset, component string, column string, id string, newT func(*Dataset, map[string]any) T) []T {
+	tbl, ok := dataset.Tables[component]
+	if !ok {
+		return nil
+	}
+	var res []T
+	for _, i := range tbl.backReferences(column)[id] {
+		res = append(res, newT(dataset, tbl.Data[i]))
+	}
+	return res
+}
+
+// backReferences returns an index mapping values of a column to the indexes of rows in Data
+// with this value - or, for list-valued columns, with this value as item. The index is built on
+// first use.
+func (tbl *Table) backReferences(column string) map[string][]int {
+	tbl.indexLock.Lock()
+	defer tbl.indexLock.Unlock()
+	if index, ok := tbl.backrefs[column]; ok {
+		return index
+	}
+	index := make(map[string][]int)
+	for i, row := range tbl.Data {
+		vals, ok := row[column].([]string)
+		if !ok {
+			vals = []string{stringValue(row, column)}
+		}
+		for _, val := range vals {
+			if rows := index[val]; val != "" && (len(rows) == 0 || rows[len(rows)-1] != i) {
+				index[val] = append(rows, i)
+			}
+		}
+	}
+	if tbl.backrefs == nil {
+		tbl.backrefs = make(map[string]map[string][]int)
+	}
+	tbl.backrefs[column] = index
+	return index
+}
+
+// Languages returns the rows of the LanguageTable as Language objects.
+func (dataset *Dataset) Languages() []*Language {
+	return objects(dataset, "LanguageTable", newLanguage)
+}
+
+// Language returns the Language with the given ID.
+func (dataset *Dataset) Language(id string) (*Language, bool) {
+	return object(dataset, "LanguageTable", id, newLanguage)
+}
+
+// Parameters returns the rows of the ParameterTable as Parameter objects.
+func (dataset *Dataset) Parameters() []*Parameter {
+	return objects(dataset, "ParameterTable", newParameter)
+}
+
+// Parameter returns the Parameter with the given ID.
+func (dataset *Dataset) Parameter(id string) (*Parameter, bool) {
+	return object(dataset, "ParameterTable", id, newParameter)
+}
+
+// Codes returns the rows of the CodeTable as Code objects.
+func (dataset *Dataset) Codes() []*Code {
+	return objects(dataset, "CodeTable", newCode)
+}
+
+// Code returns the Code with the given ID.
+func (dataset *Dataset) Code(id string) (*Code, bool) {
+	return object(dataset, "CodeTable", id, newCode)
+}
+
+// Values returns the rows of the ValueTable as Value objects.
+func (dataset *Dataset) Values() []*Value {
+	return objects(dataset, "ValueTable", newValue)
+}
+
+// Value returns the Value with the given ID.
+func (dataset *Dataset) Value(id string) (*Value, bool) {
+	return object(dataset, "ValueTable", id, newValue)
+}
+
+// Forms returns the rows of the FormTable as Form objects.
+func (dataset *Dataset) Forms() []*Form {
+	return objects(dataset, "FormTable", newForm)
+}
+
+// Form returns the Form with the given ID.
+func (dataset *Dataset) Form(id string) (*Form, bool) {
+	return object(dataset, "FormTable", id, newForm)
+}
+
+// Cognates returns the rows of the CognateTable as Cognate objects.
+func (dataset *Dataset) Cognates() []*Cognate {
+	return objects(dataset, "CognateTable", newCognate)
+}
+
+// Examples returns the rows of the ExampleTable as Example objects.
+func (dataset *Dataset) Examples() []*Example {
+	return objects(dataset, "ExampleTable", newExample)
+}
+
+// Example returns the Example with the given ID.
+func (dataset *Dataset) Example(id string) (*Example, bool) {
+	return object(dataset, "ExampleTable", id, newExample)
+}
+
+// Values returns the values for the language.
+func (l *Language) Values() []*Value {
+	return referencing(l.dataset, "ValueTable", "cldf_languageReference", l.ID, newValue)
+}
+
+// Forms returns the forms in the language.
+func (l *Language) Forms() []*Form {
+	return referencing(l.dataset, "FormTable", "cldf_languageReference", l.ID, newForm)
+}
+
+// Examples returns the examples in the language.
+func (l *Language) Examples() []*Example {
+	return referencing(l.dataset, "ExampleTable", "cldf_languageReference", l.ID, newExample)
+}
+
+// Values returns the values for the parameter.
+func (p *Parameter) Values() []*Value {
+	return referencing(p.dataset, "ValueTable", "cldf_parameterReference", p.ID, newValue)
+}
+
+// Forms returns the forms for the parameter.
+func (p *Parameter) Forms() []*Form {
+	return referencing(p.dataset, "FormTable", "cldf_parameterReference", p.ID, newForm)
+}
+
+// Codes returns the codes of the parameter.
+func (p *Parameter) Codes() []*Code {
+	return referencing(p.dataset, "CodeTable", "cldf_parameterReference", p.ID, newCode)
+}
+
+// Parameter returns the parameter of the code.
+func (c *Code) Parameter() (*Parameter, bool) {
+	return c.dataset.Parameter(c.ParameterID)
+}
+
+// Language returns the language of the value.
+func (v *Value) Language() (*Language, bool) {
+	return v.dataset.Language(v.LanguageID)
+}
+
+// Parameter returns the parameter of the value.
+func (v *Value) Parameter() (*Parameter, bool) {
+	return v.dataset.Parameter(v.ParameterID)
+}
+
+// Code returns the code of the value, if any.
+func (v *Value) Code() (*Code, bool) {
+	return v.dataset.Code(v.CodeID)
+}
+
+// Sources returns the sources referenced by the value. Unknown sources are ignored.
+func (v *Value) Sources() []*Source {
+	return v.sources()
+}
+
+// Language returns the language of the form.
+func (f *Form) Language() (*Language, bool) {
+	return f.dataset.Language(f.LanguageID)
+}
+
+// Parameter returns the parameter of the form.
+func (f *Form) Parameter() (*Parameter, bool) {
+	return f.dataset.Parameter(f.ParameterID)
+}
+
+// Cognates returns the cognate judgements for the form.
+func (f *Form) Cognates() []*Cognate {
+	return referencing(f.dataset, "CognateTable", "cldf_formReference", f.ID, newCognate)
+}
+
+// Sources returns the sources referenced by the form. Unknown sources are ignored.
+func (f *Form) Sources() []*Source {
+	return f.sources()
+}
+
+// Form returns the form of the cognate judgement.
+func (c *Cognate) Form() (*Form, bool) {
+	return c.dataset.Form(c.FormID)
+}
+
+// Sources returns the sources referenced by the cognate judgement. Unknown sources are ignored.
+func (c *Cognate) Sources() []*Source {
+	return c.sources()
+}
+
+// Language returns the language of the example.
+func (e *Example) Language() (*Language, bool) {
+	return e.dataset.Language(e.LanguageID)
+}
+
+// MetaLanguage returns the language of the translation of the example, if specified.
+func (e *Example) MetaLanguage() (*Language, bool) {
+	return e.dataset.Language(e.MetaLanguageID)
+}
@@ -0,0 +1,130 @@
+package cldf
+
+import (
+	"gocldf/cldf/ontology"
+	"testing"
+)
+
+func TestDataset_Language(t *testing.T) {
+	ds, err := GetLoadedDataset("testdata/StructureDataset-metadata.json", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ds.Languages()) != 29 || len(ds.Forms()) != 0 {
+		t.Errorf(`problem: %v`, len(ds.Languages()))
+	}
+	lang, ok := ds.Language("Santali_NM")
+	if !ok || lang.Name != "Santali" || lang.Glottocode != "sant1410" || lang.ISO639P3 != "sat" {
+		t.Fatalf(`problem: %v`, lang)
+	}
+	if lang.Latitude == nil || *lang.Latitude != 25.0317 || lang.Extra["Family_name"] != "Austroasiatic" {
+		t.Errorf(`problem: %v`, lang)
+	}
+	if len(lang.Values()) != 28 {
+		t.Errorf(`problem: %v`, len(lang.Values()))
+	}
+
+	val, ok := ds.Value("Santali_NM-2")
+	if !ok {
+		t.Fatal("value not found")
+	}
+	if l, ok := val.Language(); !ok || l.ID != "Santali_NM" {
+		t.Errorf(`problem: %v`, l)
+	}
+	param, ok := val.Parameter()
+	if !ok || param.ID != "C" || len(param.Codes()) == 0 {
+		t.Errorf(`problem: %v`, param)
+	}
+	if code, ok := val.Code(); !ok || code.ID != "C-1" {
+		t.Errorf(`problem: %v`, code)
+	}
+	sources := val.Sources()
+	if len(sources) != 2 || sources[0].Id != "Peterson2017" || val.SourceReferences[0].Context != "12ff" {
+		t.Errorf(`problem: %v`, sources)
+	}
+}
+
+func TestDataset_Forms(t *testing.T) {
+	b, _ := NewBuilder("Wordlist")
+	for _, comp := range []string{"LanguageTable", "ParameterTable", "FormTable", "CognateTable"} {
+		if _, err := b.AddComponent(comp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, _ = b.AddColumn("FormTable", map[string]any{"name": "Note"})
+	rows := []struct {
+		table string
+		row   map[string]string
+	}{
+		{"LanguageTable", map[string]string{"ID": "l1"}},
+		{"ParameterTable", map[string]string{"ID": "hand", "Name": "hand"}},
+		{"FormTable", map[string]string{"ID": "f1", "Language_ID": "l1", "Parameter_ID": "hand", "Form": "hant", "Segments": "h a n t", "Note": "x"}},
+		{"CognateTable", map[string]string{"ID": "c1", "Form_ID": "f1", "Cognateset_ID": "1"}},
+	}
+	for _, r := range rows {
+		if err := b.AddRow(r.table, r.row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ds, err := b.Dataset()
+	if err != nil {
+		t.Fatal(err)
+	}
+	forms := ds.Forms()
+	if len(forms) != 1 || len(forms[0].Segments) != 4 || forms[0].Extra["Note"] != "x" {
+		t.Fatalf(`problem: %v`, forms)
+	}
+	if p, ok := forms[0].Parameter(); !ok || p.Name != "hand" || len(p.Forms()) != 1 {
+		t.Errorf(`problem: %v`, p)
+	}
+	cognates := forms[0].Cognates()
+	if len(cognates) != 1 || cognates[0].CognatesetID != "1" {
+		t.Errorf(`problem: %v`, cognates)
+	}
+	if f, ok := cognates[0].Form(); !ok || f.Form != "hant" {
+		t.Errorf(`problem: %v`, f)
+	}
+}
+
+func TestDataset_Forms_listValuedReference(t *testing.T) {
+	b, _ := NewBuilder("Wordlist")
+	for _, comp := range []string{"LanguageTable", "ParameterTable"} {
+		if _, err := b.AddComponent(comp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	term := ontology.Namespace()
+	_, err := b.addTable("forms.csv", term+"FormTable", []map[string]any{
+		{"name": "ID", "propertyUrl": term + "id"},
+		{"name": "Language_ID", "propertyUrl": term + "languageReference"},
+		{"name": "Parameter_ID", "propertyUrl": term + "parameterReference", "separator": ";"},
+		{"name": "Form", "propertyUrl": term + "form"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := []struct {
+		table string
+		row   map[string]string
+	}{
+		{"LanguageTable", map[string]string{"ID": "l1"}},
+		{"ParameterTable", map[string]string{"ID": "hand"}},
+		{"ParameterTable", map[string]string{"ID": "arm"}},
+		{"FormTable", map[string]string{"ID": "f1", "Language_ID": "l1", "Parameter_ID": "hand;arm;hand", "Form": "a"}},
+		{"FormTable", map[string]string{"ID": "f2", "Language_ID": "l1", "Parameter_ID": "arm", "Form": "b"}},
+	}
+	for _, r := range rows {
+		if err := b.AddRow(r.table, r.row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ds, err := b.Dataset()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, expected := range map[string]int{"hand": 1, "arm": 2} {
+		if p, ok := ds.Parameter(id); !ok || len(p.Forms()) != expected {
+			t.Errorf(`problem: %v %v`, id, p)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package cldf
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"slices"
+	"strconv"
+)
+
+// csvwProperties are the properties of CSVW metadata which are not copied to the JSON output as
+// annotations of tables or table groups.
+var csvwProperties = []string{
+	"@context", "@type", "tables", "tableSchema", "dialect", "transformations", "tableDirection",
+	"url", "suppressOutput", "aboutUrl", "propertyUrl", "valueUrl", "lang", "null", "default",
+	"separator", "required", "datatype", "ordered", "textDirection",
+}
+
+/*
+ToJSON converts the data loaded into memory to JSON following the CSVW csv2json algorithm, see
+https://www.w3.org/TR/csv2json/
+
+In standard mode, the result is an object with the annotations of the table group - i.e. the
+dataset metadata - and a "tables" array, listing for each table its annotations and its rows,
+described by their url, row number and the objects they describe. In minimal mode, the result
+is the array of the objects described by the rows of all tables.
+
+Rows describe one object per subject - as specified by the aboutUrl of the columns - with cell
+values keyed by the expanded propertyUrl of the column, or the column name. Values are typed
+JSON values for numeric and boolean datatypes, arrays for list-valued columns, the expanded
+valueUrl if specified and strings otherwise. Null values are omitted.
+*/
+func (dataset *Dataset) ToJSON(minimal bool) (any, error) {
+	tables := make([]any, 0, len(dataset.Tables))
+	objects := make([]any, 0)
+	for _, tbl := range dataset.orderedByMetadata() {
+		if suppress, _ := tbl.metadata["suppressOutput"].(bool); suppress {
+			continue
+		}
+		rows := make([]any, 0, len(tbl.Data))
+		for i, row := range tbl.Data {
+			described, err := dataset.describe(tbl, row, i)
+			if err != nil {
+				return nil, err
+			}
+			if minimal {
+				objects = append(objects, described...)
+				continue
+			}
+			sourceRow := dataset.sourceRow(tbl, i)
+			rows = append(rows, map[string]any{
+				"url":       tbl.Url + "#row=" + strconv.Itoa(sourceRow),
+				"rownum":    tbl.rowNumber(i),
+				"describes": described,
+			})
+		}
+		if !minimal {
+			res := annotations(tbl.metadata)
+			res["url"] = tbl.Url
+			res["row"] = rows
+			tables = append(tables, res)
+		}
+	}
+	if minimal {
+		return objects, nil
+	}
+	res := annotations(dataset.Metadata)
+	res["tables"] = tables
+	return res, nil
+}
+
+// annotations returns the properties of a CSVW description which are copied to JSON output.
+func annotations(description map[string]any) map[string]any {
+	res := make(map[string]any)
+	for k, v := range description {
+		if !slices.Contains(csvwProperties, k) {
+			res[k] = v
+		}
+	}
+	return res
+}
+
+// sourceRow returns the line number of the i-th row of a table in its CSV file. For rows which
+// have not been read from a file, the line is derived from the row number - assuming rows do not
+// span multiple lines.
+func (dataset *Dataset) sourceRow(tbl *Table, i int) int {
+	if i < len(tbl.lines) {
+		return tbl.lines[i]
+	}
+	dialect := dataset.Dialect
+	if tbl.Dialect != nil {
+		dialect = tbl.Dialect
+	}
+	res := tbl.rowNumber(i)
+	if dialect != nil {
+		res += dialect.skipRows + dialect.headerRowCount
+	}
+	return res
+}
+
+// cell is a non-null cell of a row, with the URI templates specified for its column expanded
+// but not yet resolved.
+type cell struct {
+	col      *Column
+	value    any
+	subject  string // The expanded aboutUrl, or "" if none is specified.
+	property string // The expanded propertyUrl, or "" if none is specified.
+	valueUrl string // The expanded valueUrl, or "" if none is specified.
+}
+
+// cells returns the non-null cells of the i-th row of a table which are not suppressed.
+func (dataset *Dataset) cells(tbl *Table, row map[string]any, i int) []cell {
+	vars := tbl.TemplateVariables(row)
+	vars["_row"] = strconv.Itoa(tbl.rowNumber(i))
+	vars["_sourceRow"] = strconv.Itoa(dataset.sourceRow(tbl, i))
+
+	res := make([]cell, 0, len(tbl.Columns))
+	for j, col := range tbl.Columns {
+		if suppress, _ := tbl.columnProperty(j, "suppressOutput").(bool); suppress {
+			continue
+		}
+		val := row[col.CanonicalName]
+		if val == nil || (col.Separator != "" && len(listItems(val)) == 0) {
+			continue
+		}
+		res = append(res, cell{
+			col:      col,
+			value:    val,
+			subject:  col.ExpandAboutUrl(vars),
+			property: col.ExpandPropertyUrl(vars),
+			valueUrl: col.ExpandValueUrl(vars),
+		})
+	}
+	return res
+}
+
+// describe returns the objects described by a row, one per distinct subject of the cells.
+func (dataset *Dataset) describe(tbl *Table, row map[string]any, i int) ([]any, error) {
+	var (
+		subjects []string
+		objects  = make(map[string]map[string]any)
+	)
+	for _, c := range dataset.cells(tbl, row, i) {
+		subject := ""
+		if c.subject != "" {
+			subject = resolveUrl(tbl.Url, c.subject)
+		}
+		obj, ok := objects[subject]
+		if !ok {
+			obj = make(map[string]any)
+			if subject != "" {
+				obj["@id"] = subject
+			}
+			objects[subject] = obj
+			subjects = append(subjects, subject)
+		}
+		val, err := c.jsonValue(tbl.Url)
+		if err != nil {
+			return nil, fmt.Errorf("%v row %v column %v: %w", tbl.Url, tbl.rowNumber(i), c.col.Name, err)
+		}
+		property := c.col.Name
+		if c.property != "" {
+			property = resolveUrl(tbl.Url, c.property)
+		}
+		obj[property] = val
+	}
+	res := make([]any, len(subjects))
+	for k, subject := range subjects {
+		res[k] = objects[subject]
+	}
+	return res, nil
+}
+
+// jsonValue converts the value of a cell to a value for JSON output.
+func (c cell) jsonValue(base string) (any, error) {
+	if c.valueUrl != "" {
+		return resolveUrl(base, c.valueUrl), nil
+	}
+	if c.col.Separator == "" {
+		return atomicJsonValue(c.col, c.value)
+	}
+	items, err := c.items()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]any, len(items))
+	for k, item := range items {
+		if res[k], err = atomicJsonValue(c.col, item); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// items returns the typed items of the value of a list-valued cell. Source references are
+// returned formatted as strings.
+func (c cell) items() ([]any, error) {
+	items := listItems(c.value)
+	res := make([]any, 0, len(items))
+	for _, item := range items {
+		if _, ok := c.value.([]SourceReference); ok {
+			res = append(res, item)
+			continue
+		}
+		v, err := c.col.atomicToGo(item, true)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, v)
+	}
+	return res, nil
+}
+
+// atomicJsonValue returns numbers and booleans as is and the string representation of other
+// values.
+func atomicJsonValue(col *Column, val any) (any, error) {
+	switch v := val.(type) {
+	case bool, int:
+		return v, nil
+	case float64:
+		if !math.IsNaN(v) && !math.IsInf(v, 0) {
+			return v, nil
+		}
+	}
+	return col.ToString(val)
+}
+
+// columnProperty returns the value of an inherited property of the j-th column, as specified
+// for the column, the table schema or the table.
+func (tbl *Table) columnProperty(j int, key string) any {
+	descriptions := []map[string]any{tbl.metadata}
+	if schema, ok := tbl.metadata["tableSchema"].(map[string]any); ok {
+		descriptions = append(descriptions, schema)
+		if cols, ok := schema["columns"].([]any); ok && j < len(cols) {
+			if col, ok := cols[j].(map[string]any); ok {
+				descriptions = append(descriptions, col)
+			}
+		}
+	}
+	for _, description := range slices.Backward(descriptions) {
+		if val, ok := description[key]; ok {
+			return val
+		}
+	}
+	return nil
+}
+
+// resolveUrl resolves a URL relative to the url of a table.
+func resolveUrl(base string, ref string) string {
+	baseUrl, err := url.Parse(base)
+	if err != nil {
+		return ref
+	}
+	refUrl, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return baseUrl.ResolveReference(refUrl).String()
+}
@@ -0,0 +1,115 @@
+package cldf
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDataset_ToJSON(t *testing.T) {
+	ds, err := GetLoadedDataset("testdata/StructureDataset-metadata.json", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := ds.ToJSON(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	group := res.(map[string]any)
+	if group["rdf:ID"] != "petersonsouthasia" {
+		t.Errorf(`problem: %v`, group["rdf:ID"])
+	}
+	tables := group["tables"].([]any)
+	if len(tables) != 4 {
+		t.Fatalf(`problem: %v`, len(tables))
+	}
+	values := tables[0].(map[string]any)
+	row := values["row"].([]any)[0].(map[string]any)
+	if row["url"] != "values.csv#row=2" || row["rownum"] != 1 {
+		t.Errorf(`problem: %v`, row)
+	}
+	obj := row["describes"].([]any)[0].(map[string]any)
+	expected := []any{"Peterson2017"}
+	if actual := obj["http://cldf.clld.org/v1.0/terms.rdf#source"]; !reflect.DeepEqual(actual, expected) {
+		t.Errorf(`problem: %v vs %v`, actual, expected)
+	}
+
+	objects, err := ds.ToJSON(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var language map[string]any
+	for _, o := range objects.([]any) {
+		if o.(map[string]any)["http://cldf.clld.org/v1.0/terms.rdf#id"] == "Kharia_SM" {
+			language = o.(map[string]any)
+		}
+	}
+	if _, ok := language["http://cldf.clld.org/v1.0/terms.rdf#latitude"].(float64); !ok {
+		t.Errorf(`problem: %v`, language)
+	}
+	expectedUrl := "http://glottolog.org/resource/languoid/id/khar1287"
+	if actual := language["http://cldf.clld.org/v1.0/terms.rdf#glottocode"]; actual != expectedUrl {
+		t.Errorf(`problem: %v vs %v`, actual, expectedUrl)
+	}
+}
+
+func TestDataset_ToJSON_skippedRows(t *testing.T) {
+	ds, err := GetLoadedDataset("testdata/StructureDataset-metadata.json", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Rows of the CSV file which could not be loaded are not part of Data.
+	tbl := ds.Tables["ValueTable"]
+	tbl.Data = tbl.Data[1:]
+	tbl.rowNumbers, tbl.lines = []int{2}, tbl.lines[1:]
+	res, err := ds.ToJSON(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := res.(map[string]any)["tables"].([]any)[0].(map[string]any)
+	row := values["row"].([]any)[0].(map[string]any)
+	if row["url"] != "values.csv#row=3" || row["rownum"] != 2 {
+		t.Errorf(`problem: %v`, row)
+	}
+}
+
+// makeMultilineDataset returns a loaded dataset with a table whose rows do not start on
+// consecutive lines, because of a comment line and a cell spanning two lines.
+func makeMultilineDataset(t *testing.T) *Dataset {
+	dir := t.TempDir()
+	files := map[string]string{
+		"Generic-metadata.json": `{
+  "@context": "http://www.w3.org/ns/csvw",
+  "dc:conformsTo": "http://cldf.clld.org/v1.0/terms.rdf#Generic",
+  "dialect": {"commentPrefix": "#"},
+  "tables": [
+    {"url": "items.csv", "tableSchema": {"columns": [{"name": "ID"}, {"name": "Comment"}], "primaryKey": ["ID"]}}
+  ]
+}`,
+		"items.csv": "ID,Comment\n# a comment\na,\"first\nsecond\"\nb,x\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ds, err := GetLoadedDataset(filepath.Join(dir, "Generic-metadata.json"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ds
+}
+
+func TestDataset_ToJSON_sourceRows(t *testing.T) {
+	res, err := makeMultilineDataset(t).ToJSON(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := res.(map[string]any)["tables"].([]any)[0].(map[string]any)["row"].([]any)
+	for i, expected := range []string{"items.csv#row=3", "items.csv#row=5"} {
+		if row := rows[i].(map[string]any); row["url"] != expected || row["rownum"] != i+1 {
+			t.Errorf(`problem: %v`, row)
+		}
+	}
+}
@@ -0,0 +1,452 @@
+package cldf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RDF serialization formats supported by WriteRDF.
+const (
+	NTriples = "ntriples"
+	Turtle   = "turtle"
+)
+
+// rdfNamespaces maps prefixes used in CLDF metadata to namespace URIs. The prefixes are used to
+// expand the keys of annotations and to abbreviate URIs in Turtle output.
+var rdfNamespaces = map[string]string{
+	"cldf":   "http://cldf.clld.org/v1.0/terms.rdf#",
+	"csvw":   "http://www.w3.org/ns/csvw#",
+	"dc":     "http://purl.org/dc/terms/",
+	"dcat":   "http://www.w3.org/ns/dcat#",
+	"prov":   "http://www.w3.org/ns/prov#",
+	"rdf":    "http://www.w3.org/1999/02/22-rdf-syntax-ns#",
+	"rdfs":   "http://www.w3.org/2000/01/rdf-schema#",
+	"schema": "http://schema.org/",
+	"xsd":    "http://www.w3.org/2001/XMLSchema#",
+}
+
+// rdfDatatypes maps datatype base names to the URIs of the RDF datatypes, if these are not
+// named like the base in the XML schema namespace.
+var rdfDatatypes = map[string]string{
+	"number":   rdfNamespaces["xsd"] + "double",
+	"datetime": rdfNamespaces["xsd"] + "dateTime",
+	"binary":   rdfNamespaces["xsd"] + "base64Binary",
+	"json":     rdfNamespaces["csvw"] + "JSON",
+	"html":     rdfNamespaces["rdf"] + "HTML",
+	"xml":      rdfNamespaces["rdf"] + "XMLLiteral",
+}
+
+const rdfType = "http://www.w3.org/1999/02/22-rdf-syntax-ns#type"
+
+// iriValuedProperties are annotation properties with URIs - possibly as prefixed names - as
+// string values.
+var iriValuedProperties = []string{rdfType, rdfNamespaces["dc"] + "conformsTo"}
+
+var localName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+type termKind int
+
+const (
+	iri termKind = iota
+	blankNode
+	literal
+)
+
+// term is a node of an RDF graph, i.e. a URI, blank node or - possibly typed - literal.
+type term struct {
+	kind     termKind
+	value    string
+	datatype string // The datatype URI of typed literals
+	lang     string // The language of string literals
+}
+
+func iriTerm(s string) term { return term{kind: iri, value: s} }
+
+func stringLiteral(s string) term { return term{kind: literal, value: s} }
+
+func typedLiteral(s string, datatype string) term {
+	return term{kind: literal, value: s, datatype: datatype}
+}
+
+/*
+WriteRDF writes the data loaded into memory as RDF - serialized as N-Triples or Turtle - following
+the CSVW csv2rdf algorithm, see https://www.w3.org/TR/csv2rdf/
+
+In standard mode, the output describes the table group, its tables and rows, and - linked via
+csvw:describes - the objects described by the rows. In minimal mode, only the triples describing
+these objects are written.
+
+Subjects are the URIs expanded from aboutUrl templates, or blank nodes. Predicates are the
+expanded propertyUrls of columns, defaulting to the column name as fragment of the table URL.
+Objects are the URIs expanded from valueUrl templates or literals typed according to the column
+datatype, with one triple per item for list-valued cells. Relative URLs are resolved against
+the file URL of the metadata.
+*/
+func (dataset *Dataset) WriteRDF(w io.Writer, format string, minimal bool) error {
+	if format != NTriples && format != Turtle {
+		return fmt.Errorf("unknown RDF format %v", format)
+	}
+	base := dataset.baseUrl()
+	out := &rdfWriter{w: bufio.NewWriter(w), turtle: format == Turtle}
+	out.writePrefixes()
+
+	var group term
+	if !minimal {
+		group = out.blankNode()
+		out.write(group, iriTerm(rdfType), iriTerm(rdfNamespaces["csvw"]+"TableGroup"))
+		out.annotations(group, dataset.Metadata)
+	}
+	for _, tbl := range dataset.orderedByMetadata() {
+		if suppress, _ := tbl.metadata["suppressOutput"].(bool); suppress {
+			continue
+		}
+		tableUrl := resolveUrl(base, tbl.Url)
+		var table term
+		if !minimal {
+			table = out.blankNode()
+			out.write(group, iriTerm(rdfNamespaces["csvw"]+"table"), table)
+			out.write(table, iriTerm(rdfType), iriTerm(rdfNamespaces["csvw"]+"Table"))
+			out.write(table, iriTerm(rdfNamespaces["csvw"]+"url"), iriTerm(tableUrl))
+			out.annotations(table, tbl.metadata)
+		}
+		for i, row := range tbl.Data {
+			var rowNode term
+			if !minimal {
+				rowNode = out.blankNode()
+				out.write(table, iriTerm(rdfNamespaces["csvw"]+"row"), rowNode)
+				out.write(rowNode, iriTerm(rdfType), iriTerm(rdfNamespaces["csvw"]+"Row"))
+				out.write(rowNode, iriTerm(rdfNamespaces["csvw"]+"rownum"),
+					typedLiteral(strconv.Itoa(tbl.rowNumber(i)), rdfNamespaces["xsd"]+"integer"))
+				out.write(rowNode, iriTerm(rdfNamespaces["csvw"]+"url"),
+					iriTerm(tableUrl+"#row="+strconv.Itoa(dataset.sourceRow(tbl, i))))
+			}
+			var (
+				defaultSubject term
+				described      []string
+			)
+			for _, c := range dataset.cells(tbl, row, i) {
+				subject := defaultSubject
+				if c.subject != "" {
+					subject = iriTerm(resolveUrl(tableUrl, c.subject))
+				} else if subject.value == "" {
+					defaultSubject = out.blankNode()
+					subject = defaultSubject
+				}
+				if !minimal && !slices.Contains(described, subject.value) {
+					described = append(described, subject.value)
+					out.write(rowNode, iriTerm(rdfNamespaces["csvw"]+"describes"), subject)
+				}
+				predicate := iriTerm(tableUrl + "#" + url.PathEscape(c.col.Name))
+				if c.property != "" {
+					predicate = iriTerm(resolveUrl(tableUrl, c.property))
+				}
+				objects, err := c.rdfObjects(tableUrl)
+				if err != nil {
+					return fmt.Errorf("%v row %v column %v: %w", tbl.Url, tbl.rowNumber(i), c.col.Name, err)
+				}
+				for _, object := range objects {
+					out.write(subject, predicate, object)
+				}
+			}
+		}
+	}
+	return out.flush()
+}
+
+// baseUrl returns the file URL of the metadata of the dataset.
+func (dataset *Dataset) baseUrl() string {
+	p, err := filepath.Abs(dataset.MetadataPath)
+	if err != nil {
+		p = dataset.MetadataPath
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(p)}).String()
+}
+
+// rdfObjects returns the objects of the triples for a cell.
+func (c cell) rdfObjects(base string) ([]term, error) {
+	if c.valueUrl != "" {
+		return []term{iriTerm(resolveUrl(base, c.valueUrl))}, nil
+	}
+	if c.col.Separator == "" {
+		object, err := rdfLiteral(c.col, c.value)
+		return []term{object}, err
+	}
+	items, err := c.items()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]term, len(items))
+	for i, item := range items {
+		if res[i], err = rdfLiteral(c.col, item); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// rdfLiteral returns a literal for a value of a column, with the canonical lexical form of the
+// datatype of the column.
+func rdfLiteral(col *Column, val any) (term, error) {
+	base := col.Datatype.Base
+	var (
+		s   string
+		err error
+	)
+	switch v := val.(type) {
+	case bool:
+		s = strconv.FormatBool(v)
+	case int:
+		s = strconv.Itoa(v)
+	case float64:
+		switch {
+		case math.IsNaN(v):
+			s = "NaN"
+		case math.IsInf(v, 1):
+			s = "INF"
+		case math.IsInf(v, -1):
+			s = "-INF"
+		case base == "decimal":
+			s = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			s = strconv.FormatFloat(v, 'E', -1, 64)
+		}
+	case time.Time:
+		layout := map[string]string{
+			"date":          "2006-01-02",
+			"time":          "15:04:05.999999999",
+			"dateTimeStamp": time.RFC3339Nano,
+		}[base]
+		if layout == "" {
+			layout = "2006-01-02T15:04:05.999999999"
+		}
+		if v.Location() != time.UTC && base != "dateTimeStamp" {
+			layout += "Z07:00"
+		}
+		s = v.Format(layout)
+	default:
+		if s, err = col.ToString(val); err != nil {
+			return term{}, err
+		}
+	}
+	switch base {
+	case "string", "":
+		res := stringLiteral(s)
+		if col.Lang != "und" {
+			res.lang = col.Lang
+		}
+		return res, nil
+	}
+	datatype, ok := rdfDatatypes[base]
+	if !ok {
+		datatype = rdfNamespaces["xsd"] + base
+	}
+	return typedLiteral(s, datatype), nil
+}
+
+// rdfWriter serializes triples as N-Triples or Turtle. In Turtle, consecutive triples with the
+// same subject - and predicate - are abbreviated.
+type rdfWriter struct {
+	w         *bufio.Writer
+	turtle    bool
+	blank     int
+	subject   term
+	predicate term
+	err       error
+}
+
+func (out *rdfWriter) blankNode() term {
+	out.blank++
+	return term{kind: blankNode, value: "b" + strconv.Itoa(out.blank)}
+}
+
+func (out *rdfWriter) writePrefixes() {
+	if !out.turtle {
+		return
+	}
+	for _, prefix := range slices.Sorted(maps.Keys(rdfNamespaces)) {
+		out.printf("@prefix %v: <%v> .\n", prefix, rdfNamespaces[prefix])
+	}
+}
+
+func (out *rdfWriter) write(s, p, o term) {
+	switch {
+	case !out.turtle:
+		out.printf("%v %v %v .\n", out.format(s), out.format(p), out.format(o))
+		return
+	case s == out.subject && p == out.predicate:
+		out.printf(" ,\n        %v", out.format(o))
+	case s == out.subject:
+		out.printf(" ;\n    %v %v", out.format(p), out.format(o))
+	default:
+		if out.subject.value != "" {
+			out.printf(" .\n")
+		}
+		out.printf("\n%v %v %v", out.format(s), out.format(p), out.format(o))
+	}
+	out.subject, out.predicate = s, p
+}
+
+func (out *rdfWriter) flush() error {
+	if out.turtle && out.subject.value != "" {
+		out.printf(" .\n")
+	}
+	if out.err != nil {
+		return out.err
+	}
+	return out.w.Flush()
+}
+
+func (out *rdfWriter) printf(format string, args ...any) {
+	if out.err == nil {
+		_, out.err = fmt.Fprintf(out.w, format, args...)
+	}
+}
+
+// format returns the serialization of a term, abbreviating URIs in Turtle if possible.
+func (out *rdfWriter) format(t term) string {
+	switch t.kind {
+	case blankNode:
+		return "_:" + t.value
+	case literal:
+		res := `"` + escapeLiteral(t.value) + `"`
+		if t.lang != "" {
+			return res + "@" + t.lang
+		}
+		if t.datatype != "" {
+			return res + "^^" + out.format(iriTerm(t.datatype))
+		}
+		return res
+	}
+	if out.turtle {
+		if t.value == rdfType {
+			return "a"
+		}
+		for prefix, ns := range rdfNamespaces {
+			if local, ok := strings.CutPrefix(t.value, ns); ok && localName.MatchString(local) {
+				return prefix + ":" + local
+			}
+		}
+	}
+	return "<" + escapeIRI(t.value) + ">"
+}
+
+func escapeLiteral(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case '"':
+			b.WriteString(`\"`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+				continue
+			}
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// escapeIRI percent-encodes characters which are not allowed in IRIs in N-Triples and Turtle.
+func escapeIRI(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r <= 0x20 || strings.ContainsRune("<>\"{}|^`\\", r) {
+			fmt.Fprintf(&b, "%%%02X", r)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// annotations writes the common properties - i.e. those with a known prefix or a URL as key -
+// of a CSVW description as properties of node.
+func (out *rdfWriter) annotations(node term, description map[string]any) {
+	for _, key := range slices.Sorted(maps.Keys(description)) {
+		if key == "rdf:ID" || key == "rdf:about" || slices.Contains(csvwProperties, key) {
+			continue
+		}
+		predicate, ok := expandPrefix(key)
+		if !ok {
+			continue
+		}
+		out.annotationValues(node, iriTerm(predicate), description[key])
+	}
+}
+
+func (out *rdfWriter) annotationValues(node term, predicate term, val any) {
+	switch v := val.(type) {
+	case []any:
+		for _, item := range v {
+			out.annotationValues(node, predicate, item)
+		}
+	case string:
+		if slices.Contains(iriValuedProperties, predicate.value) {
+			if uri, ok := expandPrefix(v); ok {
+				out.write(node, predicate, iriTerm(uri))
+				return
+			}
+		}
+		out.write(node, predicate, stringLiteral(v))
+	case bool:
+		out.write(node, predicate, typedLiteral(strconv.FormatBool(v), rdfNamespaces["xsd"]+"boolean"))
+	case float64:
+		if v == math.Trunc(v) {
+			out.write(node, predicate, typedLiteral(strconv.FormatFloat(v, 'f', -1, 64), rdfNamespaces["xsd"]+"integer"))
+			return
+		}
+		out.write(node, predicate, typedLiteral(strconv.FormatFloat(v, 'E', -1, 64), rdfNamespaces["xsd"]+"double"))
+	case map[string]any:
+		if value, ok := v["@value"]; ok {
+			s := fmt.Sprint(value)
+			lang, _ := v["@language"].(string)
+			datatype, _ := v["@type"].(string)
+			datatype, _ = expandPrefix(datatype)
+			out.write(node, predicate, term{kind: literal, value: s, lang: lang, datatype: datatype})
+			return
+		}
+		var object term
+		for _, key := range []string{"@id", "rdf:about"} {
+			if id, ok := v[key].(string); ok {
+				object = iriTerm(id)
+			}
+		}
+		if object.value == "" {
+			object = out.blankNode()
+		}
+		out.write(node, predicate, object)
+		out.annotations(object, v)
+	}
+}
+
+// expandPrefix expands prefixed names with known prefixes, and returns absolute URLs as is.
+func expandPrefix(name string) (string, bool) {
+	if prefix, local, ok := strings.Cut(name, ":"); ok {
+		if ns, ok := rdfNamespaces[prefix]; ok {
+			return ns + local, true
+		}
+	}
+	if u, err := url.Parse(name); err == nil && u.IsAbs() {
+		return name, true
+	}
+	return "", false
+}
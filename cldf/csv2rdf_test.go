@@ -0,0 +1,71 @@
+package cldf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDataset_WriteRDF(t *testing.T) {
+	ds, err := GetLoadedDataset("testdata/StructureDataset-metadata.json", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := new(bytes.Buffer)
+	if err = ds.WriteRDF(out, NTriples, true); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`<http://cldf.clld.org/v1.0/terms.rdf#glottocode> <http://glottolog.org/resource/languoid/id/khar1287> .`,
+		`<http://cldf.clld.org/v1.0/terms.rdf#latitude> "22.3571"^^<http://www.w3.org/2001/XMLSchema#decimal> .`,
+		`<http://cldf.clld.org/v1.0/terms.rdf#source> "Peterson2017" .`,
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf(`problem: %v not in output`, expected)
+		}
+	}
+	if strings.Contains(out.String(), "csvw#Row") {
+		t.Error(`problem: rows described in minimal mode`)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if !strings.HasSuffix(line, " .") || strings.Count(line, " ") < 3 {
+			t.Errorf(`problem: invalid triple %v`, line)
+		}
+	}
+}
+
+func Test_rdfLiteral(t *testing.T) {
+	col, err := NewColumn(0, map[string]any{"name": "d", "datatype": map[string]any{"base": "date", "format": "dd.MM.yyyy"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	val, err := col.ToGo("03.02.2024", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lit, err := rdfLiteral(col, val)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lit.value != "2024-02-03" || lit.datatype != "http://www.w3.org/2001/XMLSchema#date" {
+		t.Errorf(`problem: %v`, lit)
+	}
+	if actual := escapeLiteral("a\"b\n\\"); actual != `a\"b\n\\` {
+		t.Errorf(`problem: %v`, actual)
+	}
+}
+
+func TestDataset_WriteRDF_sourceRows(t *testing.T) {
+	out := new(bytes.Buffer)
+	if err := makeMultilineDataset(t).WriteRDF(out, NTriples, false); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"items.csv#row=3>", "items.csv#row=5>"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf(`problem: %v not in output`, expected)
+		}
+	}
+	if strings.Contains(out.String(), "items.csv#row=4>") {
+		t.Error(`problem: row URL not at the start line of the row`)
+	}
+}
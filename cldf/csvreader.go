@@ -0,0 +1,236 @@
+package cldf
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+var bom = []byte{0xEF, 0xBB, 0xBF}
+
+/*
+CsvReader reads records from a delimiter-separated values file as described by a CSVW dialect.
+
+Unlike encoding/csv.Reader, CsvReader supports
+  - arbitrary quote characters or no quoting at all (quoteChar: null),
+  - escaping with a backslash (doubleQuote: false),
+  - custom line terminators,
+  - blank rows (skipBlankRows: false).
+
+Rows skipped via skipRows, comment lines and skipped columns are not part of the records returned
+by Read. Header rows, however, are, since only the caller knows what to do with them.
+*/
+type CsvReader struct {
+	dialect     *Dialect
+	r           *bufio.Reader
+	terminators [][]byte
+	line        int // The number of lines read so far.
+	start       int // The number of the line at which the last record started.
+	started     bool
+}
+
+func NewCsvReader(r io.Reader, dialect *Dialect) *CsvReader {
+	terminators := make([][]byte, len(dialect.lineTerminators))
+	for i, t := range dialect.lineTerminators {
+		terminators[i] = []byte(t)
+	}
+	// Longer terminators must be matched first, e.g. "\r\n" before "\r".
+	slices.SortStableFunc(terminators, func(a, b []byte) int { return cmp.Compare(len(b), len(a)) })
+	return &CsvReader{dialect: dialect, r: bufio.NewReader(r), terminators: terminators}
+}
+
+// Line returns the (1-based) line number at which the record returned by the last call of Read started.
+func (cr *CsvReader) Line() int {
+	return cr.start
+}
+
+// Read reads one record, i.e. a slice of cell values. If there are no more records, Read returns nil, io.EOF.
+func (cr *CsvReader) Read() ([]string, error) {
+	if !cr.started {
+		cr.started = true
+		if b, err := cr.r.Peek(len(bom)); err == nil && bytes.Equal(b, bom) {
+			_, _ = cr.r.Discard(len(bom))
+		}
+		for i := 0; i < cr.dialect.skipRows; i++ {
+			if _, err := cr.readLine(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for {
+		record, err := cr.readRecord()
+		if err != nil {
+			return nil, err
+		}
+		if record == nil { // A comment line.
+			continue
+		}
+		if cr.dialect.skipBlankRows && !slices.ContainsFunc(record, func(s string) bool { return s != "" }) {
+			continue
+		}
+		if cr.dialect.skipColumns >= len(record) {
+			return []string{}, nil
+		}
+		return record[cr.dialect.skipColumns:], nil
+	}
+}
+
+// readLineTerminator consumes a line terminator if one is found at the current position.
+func (cr *CsvReader) readLineTerminator() bool {
+	for _, t := range cr.terminators {
+		// Peek returns fewer bytes (and an error) at the end of the input, so they won't match.
+		if b, _ := cr.r.Peek(len(t)); bytes.Equal(b, t) {
+			_, _ = cr.r.Discard(len(t))
+			return true
+		}
+	}
+	return false
+}
+
+// readLine reads the content of a line, without any CSV parsing.
+func (cr *CsvReader) readLine() (string, error) {
+	var line strings.Builder
+	cr.line++
+	if _, err := cr.r.Peek(1); err != nil {
+		return "", err
+	}
+	for !cr.readLineTerminator() {
+		r, _, err := cr.r.ReadRune()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		line.WriteRune(r)
+	}
+	return line.String(), nil
+}
+
+// readRecord reads the cells of the next row or returns nil for a comment line.
+func (cr *CsvReader) readRecord() ([]string, error) {
+	var (
+		record   []string
+		cell     strings.Builder
+		inQuotes bool
+		atStart  = true // Whether we haven't read any content of the current cell yet.
+		d        = cr.dialect
+	)
+	if _, err := cr.r.Peek(1); err != nil {
+		return nil, err
+	}
+	cr.line++
+	startLine := cr.line
+	cr.start = startLine
+
+	if d.commentPrefix != 0 {
+		r, _, err := cr.r.ReadRune()
+		if err != nil {
+			return nil, err
+		}
+		if r == d.commentPrefix {
+			cr.line--
+			_, err = cr.readLine()
+			return nil, err
+		}
+		_ = cr.r.UnreadRune()
+	}
+
+	finishCell := func() {
+		s := cell.String()
+		switch d.trim {
+		case "true":
+			s = strings.TrimSpace(s)
+		case "start":
+			s = strings.TrimLeftFunc(s, unicode.IsSpace)
+		case "end":
+			s = strings.TrimRightFunc(s, unicode.IsSpace)
+		}
+		record = append(record, s)
+		cell.Reset()
+		atStart = true
+	}
+	// readEscaped reads the character following an escape character.
+	readEscaped := func() error {
+		r, _, err := cr.r.ReadRune()
+		if err == io.EOF {
+			if inQuotes {
+				return fmt.Errorf("line %v: unterminated quoted cell", startLine)
+			}
+			cell.WriteRune('\\')
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		cell.WriteRune(r)
+		return nil
+	}
+
+	for {
+		if !inQuotes && cr.readLineTerminator() {
+			finishCell()
+			return record, nil
+		}
+		r, _, err := cr.r.ReadRune()
+		if err == io.EOF {
+			if inQuotes {
+				return nil, fmt.Errorf("line %v: unterminated quoted cell", startLine)
+			}
+			finishCell()
+			return record, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if inQuotes {
+			switch {
+			case !d.doubleQuote && r == '\\':
+				if err = readEscaped(); err != nil {
+					return nil, err
+				}
+			case r == d.quoteChar:
+				if d.doubleQuote {
+					next, _, err := cr.r.ReadRune()
+					if err == nil && next == d.quoteChar {
+						cell.WriteRune(r)
+						continue
+					}
+					if err == nil {
+						_ = cr.r.UnreadRune()
+					}
+				}
+				inQuotes = false
+			default:
+				if r == '\n' {
+					cr.line++
+				}
+				cell.WriteRune(r)
+			}
+			continue
+		}
+		switch {
+		case r == d.delimiter:
+			finishCell()
+		case !d.doubleQuote && r == '\\':
+			atStart = false
+			if err = readEscaped(); err != nil {
+				return nil, err
+			}
+		case atStart && (d.trim == "true" || d.trim == "start") && unicode.IsSpace(r):
+			// Leading whitespace is skipped, so that we can detect quoted cells.
+		case atStart && d.quoteChar != 0 && r == d.quoteChar:
+			atStart = false
+			inQuotes = true
+		default:
+			// Quote characters within unquoted cells are just regular characters.
+			atStart = false
+			cell.WriteRune(r)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package cldf
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func readAll(t *testing.T, jsonDialect map[string]any, input string) [][]string {
+	dialect, err := NewDialect(map[string]any{"dialect": jsonDialect})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res [][]string
+	reader := NewCsvReader(strings.NewReader(input), dialect)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return res
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		res = append(res, record)
+	}
+}
+
+func TestCsvReader(t *testing.T) {
+	var tests = []struct {
+		dialect  map[string]any
+		input    string
+		expected [][]string
+	}{
+		{
+			map[string]any{},
+			"a,\"b,\"\"c\"\"\"\r\n\"d\ne\",f\n",
+			[][]string{{"a", `b,"c"`}, {"d\ne", "f"}},
+		},
+		{
+			map[string]any{"quoteChar": "'"},
+			"'a,b',\"c\"\n",
+			[][]string{{"a,b", `"c"`}},
+		},
+		{
+			map[string]any{"doubleQuote": false},
+			`"a\"b",c\,d` + "\n",
+			[][]string{{`a"b`, "c,d"}},
+		},
+		{
+			map[string]any{"quoteChar": nil, "delimiter": "\t"},
+			"\"a\t\"b\n",
+			[][]string{{`"a`, `"b`}},
+		},
+		{
+			map[string]any{"lineTerminators": "|"},
+			"a,b|c,d\n|",
+			[][]string{{"a", "b"}, {"c", "d\n"}},
+		},
+		{
+			map[string]any{},
+			"a,b\n\n,\nc,d",
+			[][]string{{"a", "b"}, {""}, {"", ""}, {"c", "d"}},
+		},
+		{
+			map[string]any{"skipBlankRows": true},
+			"a,b\n\n,\nc,d",
+			[][]string{{"a", "b"}, {"c", "d"}},
+		},
+		{
+			map[string]any{"skipRows": 1.0, "skipColumns": 1.0, "commentPrefix": "#"},
+			"\"preamble\n#comment\n1,a,b\n",
+			[][]string{{"a", "b"}},
+		},
+		{
+			map[string]any{"trim": true},
+			" \"a \" , b \n",
+			[][]string{{"a", "b"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run("Read", func(t *testing.T) {
+			res := readAll(t, tt.dialect, tt.input)
+			if !slices.EqualFunc(res, tt.expected, slices.Equal) {
+				t.Errorf(`problem: %q vs %q`, res, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCsvReader_Error(t *testing.T) {
+	dialect, _ := NewDialect(map[string]any{})
+	reader := NewCsvReader(strings.NewReader("a,b\n\"c,d\n"), dialect)
+	if _, err := reader.Read(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reader.Read(); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf(`problem: expected unterminated quote error, got %v`, err)
+	}
+}
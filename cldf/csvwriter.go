@@ -0,0 +1,119 @@
+package cldf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+/*
+CsvWriter writes records to a delimiter-separated values file as described by a CSVW dialect,
+such that CsvReader reads the same records back.
+
+Cells are quoted if necessary, i.e. if they contain the delimiter or line breaks, start with
+the quote character or - at the start of a line - with the comment prefix. If the dialect does
+not allow quoting, such cells cannot be written. Rows skipped when reading and skipped columns
+are written as empty lines and empty cells, respectively.
+*/
+type CsvWriter struct {
+	dialect    *Dialect
+	w          *bufio.Writer
+	terminator string
+	started    bool
+}
+
+func NewCsvWriter(w io.Writer, dialect *Dialect) *CsvWriter {
+	terminator := "\n"
+	if len(dialect.lineTerminators) > 0 {
+		terminator = dialect.lineTerminators[0]
+	}
+	return &CsvWriter{dialect: dialect, w: bufio.NewWriter(w), terminator: terminator}
+}
+
+// Write writes one record.
+func (cw *CsvWriter) Write(record []string) error {
+	if !cw.started {
+		cw.started = true
+		for i := 0; i < cw.dialect.skipRows; i++ {
+			if _, err := cw.w.WriteString(cw.terminator); err != nil {
+				return err
+			}
+		}
+	}
+	for i := 0; i < cw.dialect.skipColumns; i++ {
+		if err := cw.writeDelimiter(); err != nil {
+			return err
+		}
+	}
+	for i, cell := range record {
+		if i > 0 {
+			if err := cw.writeDelimiter(); err != nil {
+				return err
+			}
+		}
+		s, err := cw.formatCell(cell, i == 0 && cw.dialect.skipColumns == 0)
+		if err != nil {
+			return err
+		}
+		if _, err = cw.w.WriteString(s); err != nil {
+			return err
+		}
+	}
+	_, err := cw.w.WriteString(cw.terminator)
+	return err
+}
+
+// Flush writes any buffered data to the underlying io.Writer.
+func (cw *CsvWriter) Flush() error {
+	return cw.w.Flush()
+}
+
+func (cw *CsvWriter) writeDelimiter() error {
+	_, err := cw.w.WriteRune(cw.dialect.delimiter)
+	return err
+}
+
+// formatCell escapes and quotes the value of a cell as necessary.
+func (cw *CsvWriter) formatCell(s string, atLineStart bool) (string, error) {
+	d := cw.dialect
+	if !d.doubleQuote {
+		// Backslashes are escape characters, even outside of quoted cells.
+		s = strings.ReplaceAll(s, `\`, `\\`)
+	}
+	if !cw.needsQuotes(s, atLineStart) {
+		return s, nil
+	}
+	if d.quoteChar == 0 {
+		return "", fmt.Errorf("cell %q cannot be written without quoting", s)
+	}
+	quote := string(d.quoteChar)
+	if d.doubleQuote {
+		s = strings.ReplaceAll(s, quote, quote+quote)
+	} else {
+		s = strings.ReplaceAll(s, quote, `\`+quote)
+	}
+	return quote + s + quote, nil
+}
+
+func (cw *CsvWriter) needsQuotes(s string, atLineStart bool) bool {
+	d := cw.dialect
+	if s == "" {
+		return false
+	}
+	if strings.ContainsRune(s, d.delimiter) || (d.quoteChar != 0 && strings.HasPrefix(s, string(d.quoteChar))) {
+		return true
+	}
+	if strings.ContainsAny(s, "\r\n") {
+		return true
+	}
+	for _, t := range d.lineTerminators {
+		if strings.Contains(s, t) {
+			return true
+		}
+	}
+	if atLineStart && d.commentPrefix != 0 && strings.HasPrefix(s, string(d.commentPrefix)) {
+		return true
+	}
+	return false
+}
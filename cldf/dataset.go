@@ -1,13 +1,18 @@
 package cldf
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"gocldf/cldf/ontology"
 	"gocldf/internal/jsonutil"
 	"gocldf/internal/pathutil"
+	"iter"
+	"path"
 	"path/filepath"
 	"slices"
 	"strings"
+	"sync"
 )
 
 type Dataset struct {
@@ -16,6 +21,10 @@ type Dataset struct {
 	Dialect      *Dialect
 	Tables       map[string]*Table
 	Sources      *Sources
+	Module       string   // The CLDF module the dataset conforms to
+	UrlColumns   bool     // Whether to add columns with URLs expanded from URI templates to SQL tables
+	Namespace    string   // Prefix for SQL table names, to load multiple datasets into one database
+	order        []string // Canonical names of the tables in the order of the metadata
 }
 
 func NewDataset(mdPath string, bibtexFieldsets ...string) (*Dataset, error) {
@@ -23,18 +32,14 @@ func NewDataset(mdPath string, bibtexFieldsets ...string) (*Dataset, error) {
 	if err != nil {
 		return nil, err
 	}
-	metadata := make(map[string]any, len(result)-1)
-	for k, v := range result {
-		if k == "tables" {
-			continue
-		}
-		metadata[k] = v
-	}
-	var (
-		sourcesBibtex string
-		sources       *Sources
-	)
-	sourcesBibtex, err = jsonutil.GetString(result, "dc:source", "")
+	return newDataset(mdPath, result, bibtexFieldsets...)
+}
+
+// newDataset creates a Dataset from the metadata in result, resolving relative paths against the
+// directory of mdPath.
+func newDataset(mdPath string, result map[string]any, bibtexFieldsets ...string) (*Dataset, error) {
+	var sources *Sources
+	sourcesBibtex, err := jsonutil.GetString(result, "dc:source", "")
 	if err != nil {
 		return nil, err
 	}
@@ -44,28 +49,71 @@ func NewDataset(mdPath string, bibtexFieldsets ...string) (*Dataset, error) {
 			return nil, err
 		}
 	}
+	return datasetWithSources(mdPath, result, sources)
+}
+
+// datasetWithSources creates a Dataset from the metadata in result with the given sources, which
+// may be nil.
+func datasetWithSources(mdPath string, result map[string]any, sources *Sources) (*Dataset, error) {
+	metadata := make(map[string]any, len(result)-1)
+	for k, v := range result {
+		if k == "tables" {
+			continue
+		}
+		metadata[k] = v
+	}
 	dialect, err := NewDialect(result)
 	if err != nil {
 		return nil, err
 	}
 	res := Dataset{
-		mdPath,
-		metadata,
-		dialect,
-		make(map[string]*Table),
-		sources}
-	for _, value := range result["tables"].([]interface{}) {
+		MetadataPath: mdPath,
+		Metadata:     metadata,
+		Dialect:      dialect,
+		Tables:       make(map[string]*Table),
+		Sources:      sources,
+		Module:       detectModule(mdPath, metadata)}
+	tables, ok := result["tables"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%v does not describe a table group", mdPath)
+	}
+	for _, value := range tables {
 		tbl, err := NewTable(value.(map[string]interface{}), sources != nil)
 		if err != nil {
 			return nil, err
 		}
+		if tbl.Dialect != nil {
+			// A table dialect only overrides the properties it specifies explicitly.
+			tbl.Dialect, err = dialect.Extend(value.(map[string]interface{}))
+			if err != nil {
+				return nil, err
+			}
+		}
 		res.Tables[tbl.CanonicalName] = tbl
+		res.order = append(res.order, tbl.CanonicalName)
 	}
 	return &res, nil
 }
 
-func GetLoadedDataset(mdPath string, noChecks bool, bibtexFieldsets ...string) (ds *Dataset, err error) {
-	ds, err = NewDataset(mdPath, bibtexFieldsets...)
+// detectModule determines the CLDF module of a dataset from the dc:conformsTo property of the
+// metadata or - lacking this - from the name of the metadata file. Datasets which cannot be
+// assigned a module are Generic.
+func detectModule(mdPath string, metadata map[string]any) string {
+	if conformsTo, ok := metadata["dc:conformsTo"].(string); ok {
+		if module, ok := ontology.Term(conformsTo); ok {
+			return module
+		}
+	}
+	prefix, _, _ := strings.Cut(filepath.Base(mdPath), "-")
+	if ontology.IsModule(prefix) {
+		return prefix
+	}
+	return "Generic"
+}
+
+// GetLoadedDataset discovers the dataset at p (see Discover) and loads its data.
+func GetLoadedDataset(p string, noChecks bool, bibtexFieldsets ...string) (ds *Dataset, err error) {
+	ds, err = Discover(p, bibtexFieldsets...)
 	if err != nil {
 		return nil, err
 	}
@@ -76,6 +124,31 @@ func GetLoadedDataset(mdPath string, noChecks bool, bibtexFieldsets ...string) (
 	return ds, nil
 }
 
+/*
+Identifier returns an identifier for the dataset which can be used as Namespace, i.e. is made up of
+ASCII letters, digits and underscores only.
+
+The identifier is taken from the rdf:ID property of the metadata or - if missing - from the
+dc:identifier property, using the last path segment of URLs like DOIs. The empty string is
+returned if the metadata has neither property.
+*/
+func (dataset *Dataset) Identifier() string {
+	id, _ := jsonutil.GetString(dataset.Metadata, "rdf:ID", "")
+	if id == "" {
+		id, _ = jsonutil.GetString(dataset.Metadata, "dc:identifier", "")
+		id = path.Base(strings.TrimRight(id, "/"))
+		if id == "." || id == "/" {
+			id = ""
+		}
+	}
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			return r
+		}
+		return '_'
+	}, id)
+}
+
 func (dataset *Dataset) TablePath(tbl *Table) (string, error) {
 	res := filepath.Join(filepath.Dir(dataset.MetadataPath), tbl.Url)
 	if !pathutil.PathExists(res) {
@@ -102,6 +175,63 @@ func (dataset *Dataset) LoadData(noChecks bool) error {
 	return nil
 }
 
+// LoadDataWithErrors loads the data of all tables like LoadData, but does not stop at invalid data.
+// Instead, all validation errors - including duplicate primary keys - are collected and returned,
+// sorted by table and row. If maxErrors is positive, at most this number of errors is returned,
+// and reading a table stops once this number of errors has been found in it; such tables hold
+// only the rows read up to this point in Data. Other errors - e.g. for missing or malformed
+// files - are returned as error.
+func (dataset *Dataset) LoadDataWithErrors(maxErrors int) ([]*ValidationError, error) {
+	var (
+		mu      sync.Mutex
+		errs    []*ValidationError
+		err     error
+		dir     = filepath.Dir(dataset.MetadataPath)
+		results = make(chan TableRead, len(dataset.Tables))
+	)
+	for _, tbl := range dataset.Tables {
+		go func(tbl *Table) {
+			// The errors are collected per table, so the first maxErrors errors in the order of
+			// rows do not depend on how reading the tables is interleaved.
+			var tblErrs []*ValidationError
+			for row, err := range tbl.RowsWithErrors(context.Background(), dir, dataset.Dialect) {
+				if err != nil {
+					results <- TableRead{tbl.Url, err}
+					return
+				}
+				tblErrs = append(tblErrs, row.Errors...)
+				if row.Data != nil {
+					tbl.Data = append(tbl.Data, row.Data)
+					tbl.rowNumbers = append(tbl.rowNumbers, row.Number)
+					tbl.lines = append(tbl.lines, row.Line)
+					if verr := tbl.indexRow(row.Data, row.Number, len(tbl.Data)-1); verr != nil {
+						tblErrs = append(tblErrs, verr)
+					}
+				}
+				if maxErrors > 0 && len(tblErrs) >= maxErrors {
+					break
+				}
+			}
+			mu.Lock()
+			errs = append(errs, tblErrs...)
+			mu.Unlock()
+			results <- TableRead{tbl.Url, nil}
+		}(tbl)
+	}
+	for i := 0; i < len(dataset.Tables); i++ {
+		tableRead := <-results
+		if tableRead.Err != nil && err == nil {
+			err = tableRead.Err
+		}
+	}
+	close(results)
+	sortValidationErrors(errs)
+	if maxErrors > 0 && len(errs) > maxErrors {
+		errs = errs[:maxErrors]
+	}
+	return errs, err
+}
+
 func (dataset *Dataset) UrlToTable() map[string]*Table {
 	res := map[string]*Table{}
 	for _, tbl := range dataset.Tables {
@@ -175,13 +305,20 @@ func (dataset *Dataset) orderedTables() ([]*Table, error) {
 	return orderedTableMap, nil
 }
 
-func (dataset *Dataset) sqlSchema(noChecks bool) (string, error) {
+// sqlSchema returns the CREATE TABLE statements for the dataset in the SQL dialect d. If the dataset has a Namespace,
+// the statements also create the dataset registry and are idempotent for shared tables, so the
+// schemas of multiple datasets can be written to one database.
+func (dataset *Dataset) sqlSchema(noChecks bool, d SqlDialect) (string, error) {
 	var (
 		res        []string
 		urlToTable = dataset.UrlToTable()
+		namespaced = dataset.Namespace != ""
 	)
+	if namespaced {
+		res = append(res, sqlCreateRegistry(d))
+	}
 	if dataset.Sources != nil {
-		res = append(res, dataset.Sources.SqlCreate())
+		res = append(res, dataset.Sources.sqlCreate(namespaced, d))
 	}
 
 	orderedTableMap, err := dataset.orderedTables()
@@ -190,7 +327,7 @@ func (dataset *Dataset) sqlSchema(noChecks bool) (string, error) {
 	}
 
 	for _, tbl := range orderedTableMap {
-		schema, err := tbl.sqlCreate(urlToTable, noChecks)
+		schema, err := tbl.sqlCreate(urlToTable, noChecks, dataset.UrlColumns, dataset.Namespace, d)
 		if err != nil {
 			return "", err
 		}
@@ -198,53 +335,168 @@ func (dataset *Dataset) sqlSchema(noChecks bool) (string, error) {
 	}
 	for _, tbl := range orderedTableMap {
 		for _, fk := range tbl.ManyToMany() {
-			res = append(res, tbl.sqlCreateAssociationTable(*fk, urlToTable))
+			res = append(res, tbl.sqlCreateAssociationTable(*fk, urlToTable, dataset.Namespace, d))
 		}
 	}
+	res = append(res, sqlCreateBookkeeping(d))
 	return strings.Join(res, "\n"), nil
 }
 
+// sqlCreateRegistry returns the CREATE TABLE statement for the table listing the datasets loaded
+// into a database with namespaces.
+func sqlCreateRegistry(d SqlDialect) string {
+	return sqlCreateTable(d, "dataset", []string{
+		d.Quote("id") + "\tTEXT",
+		d.Quote("module") + "\tTEXT",
+		d.Quote("title") + "\tTEXT",
+		d.Quote("metadata_path") + "\tTEXT",
+		fmt.Sprintf("PRIMARY KEY(%v)", d.Quote("id"))})
+}
+
 type TableData struct {
 	TableName string
 	ColNames  []string
 	Rows      [][]any
 }
 
+// TableRows is the streaming counterpart of TableData.
+type TableRows struct {
+	TableName string
+	ColNames  []string
+	Rows      iter.Seq2[[]any, error]
+}
+
 // ToSqlite returns the data necessary to load the dataset into a SQLite database.
+//
+// The data is taken from the tables' Data, i.e. must have been loaded before.
 func (dataset *Dataset) ToSqlite(noChecks bool) (schema string, tableData []TableData, err error) {
-	schema, err = dataset.sqlSchema(noChecks)
+	schema, tableRows, err := dataset.toSql(noChecks, SQLite, func(tbl *Table) iter.Seq2[*Row, error] {
+		return tbl.dataRows()
+	})
 	if err != nil {
 		return "", tableData, err
 	}
+	for _, tRows := range tableRows {
+		var rows [][]any
+		for row, err := range tRows.Rows {
+			if err != nil {
+				return "", tableData, err
+			}
+			rows = append(rows, row)
+		}
+		tableData = append(tableData, TableData{tRows.TableName, tRows.ColNames, rows})
+	}
+	return schema, tableData, nil
+}
+
+// StreamToSqlite returns the schema and row iterators necessary to load the dataset into a SQLite database.
+//
+// Table data is read from the CSV files while iterating over the rows, so the dataset does not
+// need to be loaded. Since association tables are filled from the same CSV files as the tables
+// they belong to, these files are read more than once.
+//
+// Unless noChecks is true, primary key uniqueness and referential integrity are checked while
+// streaming, and iteration stops with a ValidationError at the first violation.
+func (dataset *Dataset) StreamToSqlite(ctx context.Context, noChecks bool) (schema string, tableRows []TableRows, err error) {
+	return dataset.StreamToSql(ctx, noChecks, SQLite)
+}
+
+// StreamToSql is the counterpart of StreamToSqlite for other SQL dialects, i.e. returns the schema
+// and rows with values converted for the SQL dialect d.
+func (dataset *Dataset) StreamToSql(ctx context.Context, noChecks bool, d SqlDialect) (schema string, tableRows []TableRows, err error) {
+	var checker *keyChecker
+	dir := filepath.Dir(dataset.MetadataPath)
+	if !noChecks {
+		var errs []*ValidationError
+		checker, errs = newKeyChecker(dataset)
+		if len(errs) > 0 {
+			return "", tableRows, errs[0]
+		}
+	}
+	return dataset.toSql(noChecks, d, func(tbl *Table) iter.Seq2[*Row, error] {
+		if checker != nil {
+			return checker.check(tbl, tbl.Rows(ctx, dir, dataset.Dialect, noChecks))
+		}
+		return tbl.Rows(ctx, dir, dataset.Dialect, noChecks)
+	})
+}
+
+// SqlTableName returns the name of the table in a SQLite database, prefixed with the Namespace of the
+// dataset, if any.
+func (dataset *Dataset) SqlTableName(tbl *Table) string {
+	return sqlTableName(dataset.Namespace, tbl.CanonicalName)
+}
+
+func (dataset *Dataset) toSql(
+	noChecks bool,
+	d SqlDialect,
+	tableRows func(*Table) iter.Seq2[*Row, error],
+) (schema string, res []TableRows, err error) {
+	schema, err = dataset.sqlSchema(noChecks, d)
+	if err != nil {
+		return "", res, err
+	}
 	orderedTables, err := dataset.orderedTables()
 	if err != nil {
-		return "", tableData, err
+		return "", res, err
 	}
 	urlToTable := dataset.UrlToTable()
 
+	if dataset.Namespace != "" {
+		title, _ := jsonutil.GetString(dataset.Metadata, "dc:title", "")
+		res = append(res, TableRows{"dataset", []string{"id", "module", "title", "metadata_path"},
+			func(yield func([]any, error) bool) {
+				yield([]any{dataset.Namespace, dataset.Module, title, dataset.MetadataPath}, nil)
+			}})
+	}
 	if dataset.Sources != nil {
-		rows, colNames, err := dataset.Sources.itemsToSql()
+		rows, colNames, err := dataset.Sources.itemsToSql(dataset.Namespace)
 		if err != nil {
-			return "", tableData, err
+			return "", res, err
 		}
-		tableData = append(tableData, TableData{"SourceTable", colNames, rows})
+		res = append(res, TableRows{"SourceTable", colNames, sliceRows(rows)})
 	}
 
 	for _, tbl := range orderedTables {
-		rows, colNames, err := tbl.rowsToSql()
-		if err != nil {
-			return "", tableData, err
-		}
-		tableData = append(tableData, TableData{tbl.CanonicalName, colNames, rows})
+		colNames, convert := tbl.rowConverter(dataset.UrlColumns, d)
+		res = append(res, TableRows{sqlTableName(dataset.Namespace, tbl.CanonicalName), colNames, sqlRows(
+			tableRows(tbl),
+			func(row map[string]any) ([][]any, error) {
+				val, err := convert(row)
+				return [][]any{val}, err
+			})})
 	}
 	for _, tbl := range orderedTables {
 		for _, fk := range tbl.ManyToMany() {
-			rows, tableName, colNames, err := tbl.associationTableRowsToSql(fk, urlToTable)
+			tableName, colNames, convert := tbl.associationRowConverter(fk, urlToTable, dataset.Namespace)
+			res = append(res, TableRows{tableName, colNames, sqlRows(tableRows(tbl), convert)})
+		}
+	}
+	bookkeeping, err := dataset.bookkeepingRows()
+	if err != nil {
+		return "", res, err
+	}
+	return schema, append(res, bookkeeping...), nil
+}
+
+// sqlRows turns an iterator over table rows into an iterator over rows formatted for insertion into SQLite.
+func sqlRows(rows iter.Seq2[*Row, error], convert func(map[string]any) ([][]any, error)) iter.Seq2[[]any, error] {
+	return func(yield func([]any, error) bool) {
+		for row, err := range rows {
 			if err != nil {
-				return "", tableData, err
+				yield(nil, err)
+				return
+			}
+			converted, err := convert(row.Data)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for _, val := range converted {
+				if !yield(val, nil) {
+					return
+				}
 			}
-			tableData = append(tableData, TableData{tableName, colNames, rows})
 		}
 	}
-	return schema, tableData, nil
 }
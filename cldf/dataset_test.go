@@ -1,8 +1,15 @@
 package cldf
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"gocldf/internal/dbutil"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -54,6 +61,296 @@ func TestDataset_simple(t *testing.T) {
 		if countLanguages != 29 {
 			t.Errorf(`problem: %q vs %q`, countLanguages, 29)
 		}
+		var pages string
+		err = dbutil.Query(
+			s,
+			"select context from ValueTable_SourceTable where ValueTable_cldf_id = ? and SourceTable_id = ?",
+			func(rows *sql.Rows) error {
+				return rows.Scan(&pages)
+			}, "Santali_NM-2", "Peterson2017")
+		if err != nil {
+			panic(err)
+		}
+		if pages != "12ff" {
+			t.Errorf(`problem: %q vs %q`, pages, "12ff")
+		}
 		return nil
 	}, false, true)
 }
+
+func TestDataset_StreamToSqlite(t *testing.T) {
+	ds := makeDataset("StructureDataset-metadata.json")
+	_, tableRows, err := ds.StreamToSqlite(context.Background(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := make(map[string]int)
+	for _, tRows := range tableRows {
+		for _, err := range tRows.Rows {
+			if err != nil {
+				t.Fatal(err)
+			}
+			counts[tRows.TableName]++
+		}
+	}
+	if counts["LanguageTable"] != 29 || counts["ValueTable"] != 812 {
+		t.Errorf(`problem: %v`, counts)
+	}
+	if len(ds.Tables["ValueTable"].Data) != 0 {
+		t.Errorf(`problem: streaming must not load data`)
+	}
+}
+
+func TestDataset_Identifier(t *testing.T) {
+	ds := makeDataset("StructureDataset-metadata.json")
+	if ds.Identifier() != "petersonsouthasia" {
+		t.Errorf(`problem: %v`, ds.Identifier())
+	}
+	delete(ds.Metadata, "rdf:ID")
+	if ds.Identifier() != "jsall_2017_0008" {
+		t.Errorf(`problem: %v`, ds.Identifier())
+	}
+	ds.Namespace = ds.Identifier()
+	schema, tableRows, err := ds.StreamToSqlite(context.Background(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(schema, "CREATE TABLE IF NOT EXISTS `jsall_2017_0008_ValueTable`") {
+		t.Errorf(`problem: %v`, schema)
+	}
+	if tableRows[0].TableName != "dataset" || tableRows[1].TableName != "SourceTable" {
+		t.Errorf(`problem: %v`, tableRows[0].TableName)
+	}
+}
+
+func TestDataset_LoadDataWithErrors(t *testing.T) {
+	ds := makeDataset("invalid/StructureDataset-metadata.json")
+	errs, err := ds.LoadDataWithErrors(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rules []string
+	for _, e := range errs {
+		rules = append(rules, fmt.Sprintf("%v:%v:%v:%v", e.Table, e.Row, e.Column, e.Rule))
+	}
+	expected := []string{
+		"languages.csv:1:Latitude:maxInclusive",
+		"languages.csv:2:ID:required",
+		"languages.csv:2:Latitude:datatype",
+		"languages.csv:3::cell count",
+		"values.csv:2:Language_ID:required",
+		"values.csv:3:ID:primaryKey",
+	}
+	if !slices.Equal(rules, expected) {
+		t.Errorf(`problem: %v`, rules)
+	}
+	if len(ds.Tables["LanguageTable"].Data) != 2 {
+		t.Errorf(`problem: rows with invalid cells must be loaded`)
+	}
+	if errs[0].Value != "95.0" || !strings.Contains(errs[0].Error(), "languages.csv row 1 column Latitude") {
+		t.Errorf(`problem: %v`, errs[0])
+	}
+
+	for _, maxErrors := range []int{1, 5} {
+		ds = makeDataset("invalid/StructureDataset-metadata.json")
+		errs, err = ds.LoadDataWithErrors(maxErrors)
+		if err != nil || len(errs) != maxErrors {
+			t.Fatalf(`problem: %v %v`, errs, err)
+		}
+		// The errors found are independent of the order in which the tables are read.
+		if last := errs[maxErrors-1]; fmt.Sprintf("%v:%v:%v:%v", last.Table, last.Row, last.Column, last.Rule) != expected[maxErrors-1] {
+			t.Errorf(`problem: %v`, errs)
+		}
+	}
+
+	ds = makeDataset("invalid/StructureDataset-metadata.json")
+	err = ds.LoadData(false)
+	var validationError *ValidationError
+	if !errors.As(err, &validationError) {
+		t.Errorf(`problem: expected ValidationError, got %v`, err)
+	}
+}
+
+func TestDataset_Validate(t *testing.T) {
+	ds := makeDataset("StructureDataset-metadata.json")
+	errs, err := ds.Validate(0)
+	if err != nil || len(errs) != 0 {
+		t.Errorf(`problem: %v %v`, errs, err)
+	}
+
+	ds = makeDataset("invalid/StructureDataset-metadata.json")
+	errs, err = ds.Validate(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rules []string
+	for _, e := range errs {
+		rules = append(rules, fmt.Sprintf("%v:%v:%v:%v", e.Table, e.Row, e.Column, e.Rule))
+	}
+	expected := []string{
+		"values.csv:0::property",
+		"values.csv:2:Language_ID:required",
+		"values.csv:3:ID:primaryKey",
+		"values.csv:3:Language_ID:foreignKey",
+		"values.csv:3:Source:source",
+	}
+	if !slices.Equal(rules[len(rules)-5:], expected) {
+		t.Errorf(`problem: %v`, rules)
+	}
+}
+
+func TestDataset_CheckConformance(t *testing.T) {
+	ds := makeDataset("StructureDataset-metadata.json")
+	if ds.Module != "StructureDataset" {
+		t.Errorf(`problem: %v`, ds.Module)
+	}
+	if errs := ds.CheckConformance(); len(errs) != 0 {
+		t.Errorf(`problem: %v`, errs)
+	}
+	delete(ds.Tables, "ValueTable")
+	ds.Tables["LanguageTable"].Columns[1].PropertyUrl, _ = ParseURITemplate("http://cldf.clld.org/v1.0/terms.rdf#nam")
+	var rules []string
+	for _, e := range ds.CheckConformance() {
+		rules = append(rules, fmt.Sprintf("%v:%v", e.Rule, e.Warning))
+	}
+	if !slices.Equal(rules, []string{"component:false", "property:true"}) {
+		t.Errorf(`problem: %v`, rules)
+	}
+}
+
+func TestDataset_CheckForeignKeys(t *testing.T) {
+	ds := makeDataset("fks/Generic-metadata.json")
+	if err := ds.LoadData(false); err != nil {
+		t.Fatal(err)
+	}
+	var refs []string
+	for _, e := range ds.CheckForeignKeys() {
+		refs = append(refs, fmt.Sprintf("%v:%v:%v:%v:%v", e.Table, e.Row, e.Column, e.Value, e.Rule))
+	}
+	expected := []string{
+		"refs.csv:2:Lang_IDs:l9:foreignKey",
+		"refs.csv:2:Pair_Lang,Pair_Number:l2,2:foreignKey",
+		"refs.csv:3:Parent_ID:r9:foreignKey",
+	}
+	if !slices.Equal(refs, expected) {
+		t.Errorf(`problem: %v`, refs)
+	}
+}
+
+func TestKeyChecker_deferred(t *testing.T) {
+	ds := makeDataset("fks/Generic-metadata.json")
+	if err := ds.LoadData(true); err != nil {
+		t.Fatal(err)
+	}
+	refs := ds.UrlToTable()["refs.csv"]
+	refs.Data[2]["Parent_ID"] = nil
+	checker, _ := newKeyChecker(ds)
+	// The references to langs.csv must be checked once langs.csv is complete, after refs.csv.
+	for _, err := range checker.check(refs, refs.dataRows()) {
+		if err != nil {
+			t.Fatalf(`problem: %v`, err)
+		}
+	}
+	var verr *ValidationError
+	langs := ds.UrlToTable()["langs.csv"]
+	for _, err := range checker.check(langs, langs.dataRows()) {
+		if err != nil {
+			errors.As(err, &verr)
+		}
+	}
+	if verr == nil || verr.Table != "refs.csv" || verr.Row != 2 || verr.Value != "l9" {
+		t.Errorf(`problem: %v`, verr)
+	}
+}
+
+func TestDataset_StreamToSqlite_invalid(t *testing.T) {
+	ds := makeDataset("fks/Generic-metadata.json")
+	_, tableRows, err := ds.StreamToSqlite(context.Background(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tRows := range tableRows {
+		for _, err = range tRows.Rows {
+			if err != nil {
+				break
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	var verr *ValidationError
+	if !errors.As(err, &verr) || verr.Table != "refs.csv" || verr.Row != 2 || verr.Value != "l9" {
+		t.Errorf(`problem: %v`, err)
+	}
+
+	_, tableRows, _ = ds.StreamToSqlite(context.Background(), true)
+	for _, tRows := range tableRows {
+		for _, err = range tRows.Rows {
+			if err != nil {
+				t.Errorf(`problem: %v`, err)
+			}
+		}
+	}
+}
+
+func TestDataset_Validate_maxErrors(t *testing.T) {
+	dir := t.TempDir()
+	term := "http://cldf.clld.org/v1.0/terms.rdf#"
+	files := map[string]string{
+		"Wordlist-metadata.json": `{
+  "@context": "http://www.w3.org/ns/csvw",
+  "dc:conformsTo": "` + term + `Wordlist",
+  "tables": [
+    {"url": "languages.csv", "dc:conformsTo": "` + term + `LanguageTable", "tableSchema": {
+      "columns": [
+        {"name": "ID", "propertyUrl": "` + term + `id"},
+        {"name": "Latitude", "propertyUrl": "` + term + `latitude", "datatype": {"base": "decimal", "minimum": -90, "maximum": 90}},
+        {"name": "Unknown", "propertyUrl": "` + term + `unknownProperty"}
+      ],
+      "primaryKey": ["ID"]}},
+    {"url": "forms.csv", "dc:conformsTo": "` + term + `FormTable", "tableSchema": {
+      "columns": [
+        {"name": "ID", "propertyUrl": "` + term + `id"},
+        {"name": "Language_ID", "propertyUrl": "` + term + `languageReference"},
+        {"name": "Parameter_ID", "propertyUrl": "` + term + `parameterReference"},
+        {"name": "Form", "propertyUrl": "` + term + `form"}
+      ],
+      "primaryKey": ["ID"],
+      "foreignKeys": [{"columnReference": ["Language_ID"], "reference": {"resource": "languages.csv", "columnReference": ["ID"]}}]}}
+  ]
+}`,
+		"languages.csv": "ID,Latitude,Unknown\nl1,100,\nl2,100,\nl3,10,\n",
+		"forms.csv":     "ID,Language_ID,Parameter_ID,Form\nf1,l3,p1,a\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, maxErrors := range []int{0, 2, 1} {
+		ds, err := NewDataset(filepath.Join(dir, "Wordlist-metadata.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		errs, err := ds.Validate(maxErrors)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var rules []string
+		for _, e := range errs {
+			rules = append(rules, fmt.Sprintf("%v:%v:%v:%v", e.Table, e.Row, e.Column, e.Warning))
+		}
+		expected := []string{
+			"languages.csv:0:Unknown:true",
+			"languages.csv:1:Latitude:false",
+			"languages.csv:2:Latitude:false",
+		}
+		if maxErrors == 1 {
+			expected = expected[:2]
+		}
+		if !slices.Equal(rules, expected) {
+			t.Errorf(`problem: %v %v`, maxErrors, rules)
+		}
+	}
+}
@@ -23,6 +23,7 @@ var anyURI = baseType{
 		return u.String(), nil
 	},
 	sqlType: "TEXT",
+	goType:  "*url.URL",
 	toSql: func(dt *Datatype, x any) (any, error) {
 		u := x.(*url.URL)
 		return u.String(), nil
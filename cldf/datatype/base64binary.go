@@ -20,6 +20,7 @@ var base64binary = baseType{
 	},
 	toString: _base64ToString,
 	sqlType:  "TEXT",
+	goType:   "[]byte",
 	toSql: func(dt *Datatype, x any) (any, error) {
 		return _base64ToString(dt, x)
 	},
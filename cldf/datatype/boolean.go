@@ -31,6 +31,7 @@ var boolean = baseType{
 		return dt.DerivedDescription["false"].([]string)[0], nil
 	},
 	sqlType: "INTEGER",
+	goType:  "bool",
 	toSql: func(dt *Datatype, x any) (any, error) {
 		if x.(bool) {
 			return 1, nil
@@ -30,6 +30,8 @@ package datatype
 import (
 	"fmt"
 	"gocldf/internal/jsonutil"
+	"slices"
+	"strings"
 )
 
 /*
@@ -46,6 +48,8 @@ roundtrip-safe way.
 
 sqlType specifies the best matching SQLite data type.
 
+goType specifies the type of the Go objects returned by toGo, formatted as in Go source code.
+
 toSql implements the conversion of the Go object to a suitable object for insertion
 into a SQLite database.
 */
@@ -54,6 +58,7 @@ type baseType struct {
 	toGo                  func(*Datatype, string, bool) (any, error)
 	toString              func(*Datatype, any) (string, error)
 	sqlType               string
+	goType                string
 	toSql                 func(*Datatype, any) (any, error)
 }
 
@@ -66,25 +71,27 @@ func zeroGetDerivedDescription(m map[string]any, _ map[string]stringAndAny) (map
 
 // baseTypes provides a mapping of CSVW data type base names to baseType instances.
 var baseTypes = map[string]baseType{
-	"boolean":       boolean,
-	"string":        String,
-	"html":          String,
-	"xml":           String,
-	"anyURI":        anyURI,
-	"base64Binary":  base64binary,
-	"binary":        base64binary,
-	"integer":       integer, // FIXME: add long, short, byte as aliases
-	"int":           integer,
-	"decimal":       decimal,
-	"float":         decimal,
-	"number":        decimal,
-	"double":        decimal,
-	"json":          Json,
-	"time":          Time,
-	"date":          date,
-	"datetime":      dateTime,
-	"dateTime":      dateTime,
-	"dateTimeStamp": dateTimeStamp,
+	"boolean":          boolean,
+	"string":           String,
+	"normalizedString": String,
+	"anyAtomicType":    String,
+	"html":             String,
+	"xml":              String,
+	"anyURI":           anyURI,
+	"base64Binary":     base64binary,
+	"binary":           base64binary,
+	"integer":          integer, // FIXME: add long, short, byte as aliases
+	"int":              integer,
+	"decimal":          decimal,
+	"float":            decimal,
+	"number":           decimal,
+	"double":           decimal,
+	"json":             Json,
+	"time":             Time,
+	"date":             date,
+	"datetime":         dateTime,
+	"dateTime":         dateTime,
+	"dateTimeStamp":    dateTimeStamp,
 	// FIXME: missing: gDay etc, duration, hexBinary, QName
 }
 
@@ -104,6 +111,20 @@ type Datatype struct {
 	DerivedDescription map[string]any
 }
 
+// ConstraintError is returned when a value violates a constraint of a datatype description.
+type ConstraintError struct {
+	Constraint string // The name of the constraint, e.g. "minLength" or "format".
+	Message    string
+}
+
+func (e *ConstraintError) Error() string {
+	return e.Message
+}
+
+func constraintError(constraint string, message string) error {
+	return &ConstraintError{Constraint: constraint, Message: message}
+}
+
 type stringAndAny struct {
 	str string
 	val any
@@ -183,8 +204,12 @@ func New(jsonCol map[string]interface{}) (*Datatype, error) {
 			}
 		}
 	}
+	bt, ok := baseTypes[base]
+	if !ok {
+		return nil, fmt.Errorf("unsupported datatype %v", base)
+	}
 	// We compute the derived description map once at instantiation.
-	dd, err := baseTypes[base].getDerivedDescription(dtProps, valueConstraints)
+	dd, err := bt.getDerivedDescription(dtProps, valueConstraints)
 	if err != nil {
 		return nil, err
 	}
@@ -203,6 +228,30 @@ func New(jsonCol map[string]interface{}) (*Datatype, error) {
 	return res, nil
 }
 
+// IsString returns whether values of the datatype are strings, i.e. whether whitespace in
+// values must be preserved.
+func (dt *Datatype) IsString() bool {
+	return slices.Contains([]string{"string", "json", "xml", "html", "anyAtomicType"}, dt.Base)
+}
+
+// Normalize implements the whitespace normalization of cell values, i.e. steps 1 and 2 of the
+// cell parsing procedure.
+func (dt *Datatype) Normalize(s string) string {
+	if dt.IsString() {
+		return s
+	}
+	s = strings.Map(func(r rune) rune {
+		if r == '\r' || r == '\n' || r == '\t' {
+			return ' '
+		}
+		return r
+	}, s)
+	if dt.Base == "normalizedString" {
+		return s
+	}
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool { return r == ' ' }), " ")
+}
+
 func (dt *Datatype) ToString(val any) (string, error) {
 	return baseTypes[dt.Base].toString(dt, val)
 }
@@ -215,9 +264,47 @@ func (dt *Datatype) SqlType() string {
 	return baseTypes[dt.Base].sqlType
 }
 
+// GoType returns the type of the values returned by ToGo, formatted as in Go source code,
+// e.g. "time.Time".
+func (dt *Datatype) GoType() string {
+	return baseTypes[dt.Base].goType
+}
+
 func (dt *Datatype) ToSql(val any) (any, error) {
 	if val == nil {
 		return nil, nil
 	}
 	return baseTypes[dt.Base].toSql(dt, val)
 }
+
+// FromSql converts a value read from a SQLite database - as inserted after conversion with
+// ToSql - back into the Go object of the datatype.
+func (dt *Datatype) FromSql(val any) (any, error) {
+	switch v := val.(type) {
+	case nil:
+		return nil, nil
+	case int64:
+		switch baseTypes[dt.Base].goType {
+		case "bool":
+			return v != 0, nil
+		case "float64":
+			return float64(v), nil
+		case "int":
+			return int(v), nil
+		}
+		return dt.ToGo(fmt.Sprint(v), true)
+	case float64:
+		switch baseTypes[dt.Base].goType {
+		case "float64":
+			return v, nil
+		case "int":
+			return int(v), nil
+		}
+		return dt.ToGo(fmt.Sprint(v), true)
+	case []byte:
+		return dt.ToGo(string(v), true)
+	case string:
+		return dt.ToGo(v, true)
+	}
+	return nil, fmt.Errorf("unsupported SQL value %v", val)
+}
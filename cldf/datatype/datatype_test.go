@@ -2,6 +2,7 @@ package datatype
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
 	"testing"
@@ -255,3 +256,84 @@ func TestDatatype_ToSql(t *testing.T) {
 		})
 	}
 }
+
+func TestDatatype_FromSql(t *testing.T) {
+	var tests = []struct {
+		datatype string
+		input    string
+	}{
+		{`{"base": "boolean","format":"yes|no"}`, "no"},
+		{`{"base": "binary"}`, "SGVsbG8gV29ybGQ="},
+		{`{"base": "integer"}`, "5"},
+		{`{"base": "decimal"}`, "1.1"},
+		{`{"base":"json"}`, `{"k":5}`},
+		{`{"base":"anyURI"}`, "http://example.org"},
+		{`{"base":"date","format":"yyyy-MM-ddX"}`, "2018-12-10Z"},
+	}
+	for _, tt := range tests {
+		t.Run("FromSql", func(t *testing.T) {
+			dt := makeDatatype(tt.datatype)
+			val, _ := dt.ToGo(tt.input, true)
+			sqlVal, err := dt.ToSql(val)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if i, ok := sqlVal.(int); ok {
+				// The SQLite driver returns integers as int64.
+				sqlVal = int64(i)
+			}
+			val, err = dt.FromSql(sqlVal)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s, _ := dt.ToString(val); s != tt.input {
+				t.Errorf(`problem: %v vs %v`, tt.input, s)
+			}
+		})
+	}
+}
+
+func TestDatatype_Normalize(t *testing.T) {
+	var tests = []struct {
+		datatype string
+		input    string
+		expected string
+	}{
+		{`"string"`, " a\tb ", " a\tb "},
+		{`"json"`, "{\n}", "{\n}"},
+		{`"integer"`, " \t1\r\n", "1"},
+		{`"normalizedString"`, " a\tb ", " a b "},
+		{`"date"`, "  2018-12-10  ", "2018-12-10"},
+	}
+	for _, tt := range tests {
+		t.Run("Normalize", func(t *testing.T) {
+			dt := makeDatatype(tt.datatype)
+			if val := dt.Normalize(tt.input); val != tt.expected {
+				t.Errorf(`problem: %q vs %q`, tt.expected, val)
+			}
+		})
+	}
+}
+
+func TestDatatype_ConstraintError(t *testing.T) {
+	var tests = []struct {
+		datatype   string
+		input      string
+		constraint string
+	}{
+		{`{"base":"string","minLength":3}`, "ab", "minLength"},
+		{`{"base":"string","format":"[a-z]+"}`, "1", "format"},
+		{`{"base":"integer","maxExclusive":"5"}`, "5", "maxExclusive"},
+		{`{"base":"date","minimum":"2000-01-01"}`, "1999-12-31", "minInclusive"},
+	}
+	for _, tt := range tests {
+		t.Run("ConstraintError", func(t *testing.T) {
+			dt := makeDatatype(tt.datatype)
+			_, err := dt.ToGo(tt.input, false)
+			var ce *ConstraintError
+			if !errors.As(err, &ce) || ce.Constraint != tt.constraint {
+				t.Errorf(`problem: %v vs %v`, tt.constraint, err)
+			}
+		})
+	}
+}
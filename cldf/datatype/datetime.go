@@ -85,16 +85,16 @@ func toGo(dt *Datatype, s string, noChecks bool) (any, error) {
 	}
 	if !noChecks {
 		if dt.MinInclusive != nil && val.Before(dt.MinInclusive.(time.Time)) {
-			return nil, errors.New("value smaller than minimum")
+			return nil, constraintError("minInclusive", "value smaller than minimum")
 		}
 		if dt.MaxInclusive != nil && val.After(dt.MaxInclusive.(time.Time)) {
-			return nil, errors.New("value greater than maximum")
+			return nil, constraintError("maxInclusive", "value greater than maximum")
 		}
 		if dt.MinExclusive != nil && (val.Equal(dt.MinExclusive.(time.Time)) || val.Before(dt.MinExclusive.(time.Time))) {
-			return nil, errors.New("value smaller than exclusive minimum")
+			return nil, constraintError("minExclusive", "value smaller than exclusive minimum")
 		}
 		if dt.MaxExclusive != nil && (val.Equal(dt.MaxExclusive.(time.Time)) || val.After(dt.MaxExclusive.(time.Time))) {
-			return nil, errors.New("value greater than exclusive maximum")
+			return nil, constraintError("maxExclusive", "value greater than exclusive maximum")
 		}
 	}
 	return val, nil
@@ -131,6 +131,7 @@ var dateTime = baseType{
 	toGo:     toGo,
 	toString: toString,
 	sqlType:  "TEXT",
+	goType:   "time.Time",
 	toSql:    toSql,
 }
 
@@ -166,6 +167,7 @@ var dateTimeStamp = baseType{
 	toGo:     toGo,
 	toString: toString,
 	sqlType:  "TEXT",
+	goType:   "time.Time",
 	toSql:    toSql,
 }
 
@@ -193,6 +195,7 @@ var date = baseType{
 	toGo:     toGo,
 	toString: toString,
 	sqlType:  "TEXT",
+	goType:   "time.Time",
 	toSql:    toSql,
 }
 
@@ -220,5 +223,6 @@ var Time = baseType{
 	toGo:     toGo,
 	toString: toString,
 	sqlType:  "TEXT",
+	goType:   "time.Time",
 	toSql:    toSql,
 }
@@ -1,7 +1,6 @@
 package datatype
 
 import (
-	"errors"
 	"fmt"
 	"strconv"
 )
@@ -23,16 +22,16 @@ var decimal = baseType{
 		}
 		if !noChecks {
 			if dt.MinInclusive != nil && val < dt.MinInclusive.(float64) {
-				return nil, errors.New("value smaller than minimum")
+				return nil, constraintError("minInclusive", "value smaller than minimum")
 			}
 			if dt.MaxInclusive != nil && val > dt.MaxInclusive.(float64) {
-				return nil, errors.New("value greater than maximum")
+				return nil, constraintError("maxInclusive", "value greater than maximum")
 			}
 			if dt.MinExclusive != nil && val <= dt.MinExclusive.(float64) {
-				return nil, errors.New("value smaller than exclusive minimum")
+				return nil, constraintError("minExclusive", "value smaller than exclusive minimum")
 			}
 			if dt.MaxExclusive != nil && val >= dt.MaxExclusive.(float64) {
-				return nil, errors.New("value greater than exclusive maximum")
+				return nil, constraintError("maxExclusive", "value greater than exclusive maximum")
 			}
 		}
 		return val, nil
@@ -41,6 +40,7 @@ var decimal = baseType{
 		return fmt.Sprintf("%g", x.(float64)), nil
 	},
 	sqlType: "REAL",
+	goType:  "float64",
 	toSql: func(dt *Datatype, x any) (any, error) {
 		return x.(float64), nil
 	},
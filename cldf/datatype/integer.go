@@ -1,7 +1,6 @@
 package datatype
 
 import (
-	"errors"
 	"strconv"
 )
 
@@ -22,16 +21,16 @@ var integer = baseType{
 		}
 		if !noChecks {
 			if dt.MinInclusive != nil && val < dt.MinInclusive.(int) {
-				return nil, errors.New("value smaller than minimum")
+				return nil, constraintError("minInclusive", "value smaller than minimum")
 			}
 			if dt.MaxInclusive != nil && val > dt.MaxInclusive.(int) {
-				return nil, errors.New("value greater than maximum")
+				return nil, constraintError("maxInclusive", "value greater than maximum")
 			}
 			if dt.MinExclusive != nil && val <= dt.MinExclusive.(int) {
-				return nil, errors.New("value smaller than exclusive minimum")
+				return nil, constraintError("minExclusive", "value smaller than exclusive minimum")
 			}
 			if dt.MaxExclusive != nil && val >= dt.MaxExclusive.(int) {
-				return nil, errors.New("value greater than exclusive maximum")
+				return nil, constraintError("maxExclusive", "value greater than exclusive maximum")
 			}
 		}
 		return val, nil
@@ -40,6 +39,7 @@ var integer = baseType{
 		return strconv.Itoa(x.(int)), nil
 	},
 	sqlType: "INTEGER",
+	goType:  "int",
 	toSql: func(dt *Datatype, x any) (any, error) {
 		return x.(int), nil
 	},
@@ -24,6 +24,7 @@ var Json = baseType{
 	},
 	toString: _jsonToString,
 	sqlType:  "TEXT",
+	goType:   "any",
 	toSql: func(dt *Datatype, x any) (any, error) {
 		return _jsonToString(dt, x)
 	},
@@ -31,17 +31,17 @@ var String = baseType{
 	toGo: func(dt *Datatype, s string, noChecks bool) (any, error) {
 		if !noChecks {
 			if dt.Length != -1 && len(s) != dt.Length {
-				return nil, errors.New("invalid length")
+				return nil, constraintError("length", "invalid length")
 			}
 			if dt.MinLength != -1 && len(s) < dt.MinLength {
-				return nil, errors.New("invalid length")
+				return nil, constraintError("minLength", "invalid length")
 			}
 			if dt.MaxLength != -1 && len(s) > dt.MaxLength {
-				return nil, errors.New("invalid length")
+				return nil, constraintError("maxLength", "invalid length")
 			}
 			if dt.DerivedDescription["regex"] != nil {
 				if !dt.DerivedDescription["regex"].(*regexp.Regexp).MatchString(s) {
-					return nil, errors.New("invalid value")
+					return nil, constraintError("format", "invalid value")
 				}
 			}
 		}
@@ -51,5 +51,6 @@ var String = baseType{
 		return x.(string), nil
 	},
 	sqlType: "TEXT",
+	goType:  "string",
 	toSql:   func(dt *Datatype, x any) (any, error) { return x.(string), nil },
 }
@@ -1,36 +1,37 @@
 /*
+The CSVW dialect describes how to read the rows and cells of a delimiter-separated values file.
+See https://www.w3.org/TR/tabular-metadata/#dialect-descriptions
+
 Limitations:
-  - go's csv.Reader always skips blank rows, see https://github.com/golang/go/issues/39119
-    so the default (false) for skipBlankRows cannot be implemented currently.
+  - CLDF requires UTF-8 encoded files, so the encoding property is ignored.
 */
 package cldf
 
 import (
-	"encoding/csv"
 	"errors"
+	"fmt"
 	"gocldf/internal/jsonutil"
 	"slices"
 )
 
 type Dialect struct {
-	commentPrefix rune // Reader.Comment rune
-	delimiter     rune // Reader.Comma rune
+	commentPrefix rune // 0 if comment lines are not recognized
+	delimiter     rune
 
 	// CLDF requires UTF-8 encoded files
 	//encoding        string
 	header          bool
 	headerRowCount  int
 	lineTerminators []string
-	quoteChar       string
+	quoteChar       rune // 0 if cells are not quoted
 
-	// go's csv.Reader always skips blank rows https://github.com/golang/go/issues/39119
 	skipBlankRows    bool
 	skipColumns      int
 	skipInitialSpace bool // trimString = "start"
 
 	skipRows int
 
-	doubleQuote bool
+	doubleQuote bool // If false, the escape character is a backslash
 	trim        string
 }
 
@@ -40,39 +41,110 @@ func NewDialect(jsonTableOrTableGroup map[string]any) (*Dialect, error) {
 		delimiter:        []rune(",")[0],
 		header:           true,
 		headerRowCount:   1,
-		lineTerminators:  []string{"\r\n", "\n"}, // not yet supported
-		quoteChar:        `"`,                    // not yet supported
+		lineTerminators:  []string{"\r\n", "\n"},
+		quoteChar:        '"',
 		skipBlankRows:    false,
-		skipColumns:      0, // not yet supported
+		skipColumns:      0,
 		skipInitialSpace: false,
-		skipRows:         0,    // not yet supported
-		doubleQuote:      true, // false not yet supported
+		skipRows:         0,
+		doubleQuote:      true,
 		trim:             "false",
 	}
-	jsonDialect, ok := jsonTableOrTableGroup["dialect"]
+	return res.Extend(jsonTableOrTableGroup)
+}
+
+// Extend returns a copy of the dialect, updated with the properties specified in the
+// dialect object of a table or table group. Properties which are not specified are inherited,
+// thus a table-level dialect can be merged into the table group's dialect.
+func (d *Dialect) Extend(jsonTableOrTableGroup map[string]any) (*Dialect, error) {
+	res := *d
+	val, ok := jsonTableOrTableGroup["dialect"]
+	if !ok {
+		return &res, nil
+	}
+	jsonDialect, ok := val.(map[string]any)
 	if !ok {
-		return res, nil
+		return nil, errors.New("invalid 'dialect' format")
+	}
+	r, err := jsonutil.GetRune(jsonDialect, "commentPrefix", res.commentPrefix)
+	if err != nil {
+		return nil, err
+	}
+	res.commentPrefix = r
+	r, err = jsonutil.GetRune(jsonDialect, "delimiter", res.delimiter)
+	if err != nil {
+		return nil, err
+	}
+	res.delimiter = r
+	r, err = jsonutil.GetRune(jsonDialect, "quoteChar", res.quoteChar)
+	if err != nil {
+		return nil, err
 	}
-	val, err := jsonutil.GetRune(jsonDialect.(map[string]any), "commentPrefix", rune(0))
+	res.quoteChar = r
+	res.doubleQuote, err = jsonutil.GetBool(jsonDialect, "doubleQuote", res.doubleQuote)
 	if err != nil {
 		return nil, err
 	}
-	res.commentPrefix = val
-	val, err = jsonutil.GetRune(jsonDialect.(map[string]any), "delimiter", rune(','))
+	res.skipBlankRows, err = jsonutil.GetBool(jsonDialect, "skipBlankRows", res.skipBlankRows)
+	if err != nil {
+		return nil, err
+	}
+	lineTerminators, ok := jsonDialect["lineTerminators"]
+	if ok {
+		switch val := lineTerminators.(type) {
+		case string:
+			res.lineTerminators = []string{val}
+		case []any:
+			res.lineTerminators, err = jsonutil.GetStringArray(jsonDialect, "lineTerminators")
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, errors.New("invalid 'lineTerminators' format")
+		}
+		if len(res.lineTerminators) == 0 || slices.Contains(res.lineTerminators, "") {
+			return nil, errors.New("invalid 'lineTerminators' format")
+		}
+	}
+	if res.delimiter == 0 || res.delimiter == res.quoteChar || res.delimiter == res.commentPrefix {
+		return nil, fmt.Errorf("invalid delimiter %q", res.delimiter)
+	}
+	header, err := jsonutil.GetBool(jsonDialect, "header", res.header)
 	if err != nil {
 		return nil, err
 	}
-	res.delimiter = val
-	header, err := jsonutil.GetBool(jsonDialect.(map[string]any), "header", true)
+	_, hasHeader := jsonDialect["header"]
+	_, hasHeaderRowCount := jsonDialect["headerRowCount"]
+	headerRowCount, err := jsonutil.GetInt(jsonDialect, "headerRowCount", res.headerRowCount)
 	if err != nil {
 		return nil, err
 	}
+	if hasHeader && !header {
+		// No header overrules any headerRowCount.
+		headerRowCount = 0
+	} else if hasHeader && !hasHeaderRowCount {
+		headerRowCount = 1
+	} else if !hasHeader && hasHeaderRowCount {
+		header = headerRowCount > 0
+	}
 	res.header = header
-	skipSpace, err := jsonutil.GetBool(jsonDialect.(map[string]any), "skipInitialSpace", false)
+	res.headerRowCount = headerRowCount
+	res.skipRows, err = jsonutil.GetInt(jsonDialect, "skipRows", res.skipRows)
+	if err != nil {
+		return nil, err
+	}
+	res.skipColumns, err = jsonutil.GetInt(jsonDialect, "skipColumns", res.skipColumns)
+	if err != nil {
+		return nil, err
+	}
+	if res.headerRowCount < 0 || res.skipRows < 0 || res.skipColumns < 0 {
+		return nil, errors.New("headerRowCount, skipRows and skipColumns must be non-negative")
+	}
+	skipSpace, err := jsonutil.GetBool(jsonDialect, "skipInitialSpace", false)
 	if err != nil {
 		return nil, err
 	}
-	trim, ok := jsonDialect.(map[string]any)["trim"]
+	trim, ok := jsonDialect["trim"]
 	if ok { // Explicit trim flag, we ignore skipInitialSpace.
 		trimBool, ok := trim.(bool)
 		if ok {
@@ -96,30 +168,5 @@ func NewDialect(jsonTableOrTableGroup map[string]any) (*Dialect, error) {
 	} else if skipSpace { // No trim flag, so we honor skipInitialSpace.
 		res.trim = "start"
 	}
-	return res, nil
-}
-
-func (d *Dialect) ConfigureCsvReader(reader *csv.Reader) {
-	// Comma is the field delimiter.
-	// It is set to comma (',') by NewReader.
-	// Comma must be a valid rune and must not be \r, \n,
-	// or the Unicode replacement character (0xFFFD).
-	reader.Comma = d.delimiter
-
-	// Comment, if not 0, is the comment character. Lines beginning with the
-	// Comment character without preceding whitespace are ignored.
-	// With leading whitespace the Comment character becomes part of the
-	// field, even if TrimLeadingSpace is true.
-	// Comment must be a valid rune and must not be \r, \n,
-	// or the Unicode replacement character (0xFFFD).
-	// It must also not be equal to Comma.
-	if d.commentPrefix != rune(0) {
-		reader.Comment = d.commentPrefix
-	}
-
-	// If TrimLeadingSpace is true, leading white space in a field is ignored.
-	// This is done even if the field delimiter, Comma, is white space.
-	if d.trim == "start" {
-		reader.TrimLeadingSpace = true
-	}
+	return &res, nil
 }
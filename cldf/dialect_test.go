@@ -0,0 +1,32 @@
+package cldf
+
+import (
+	"testing"
+)
+
+func TestDialect_Extend(t *testing.T) {
+	group, err := NewDialect(map[string]any{"dialect": map[string]any{"delimiter": "\t", "headerRowCount": 2.0}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !group.header || group.headerRowCount != 2 {
+		t.Errorf(`problem: %v`, group)
+	}
+	tbl, err := group.Extend(map[string]any{"dialect": map[string]any{"skipRows": 1.0}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tbl.delimiter != '\t' || tbl.headerRowCount != 2 || tbl.skipRows != 1 {
+		t.Errorf(`problem: %v`, tbl)
+	}
+	tbl, err = group.Extend(map[string]any{"dialect": map[string]any{"header": false}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tbl.header || tbl.headerRowCount != 0 {
+		t.Errorf(`problem: %v`, tbl)
+	}
+	if _, err = NewDialect(map[string]any{"dialect": map[string]any{"skipColumns": -1.0}}); err == nil {
+		t.Errorf(`problem: expected error`)
+	}
+}
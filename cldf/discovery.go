@@ -0,0 +1,229 @@
+package cldf
+
+import (
+	"errors"
+	"fmt"
+	"gocldf/cldf/ontology"
+	"gocldf/internal/jsonutil"
+	"gocldf/internal/pathutil"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// metadataFreeModules maps the names of data files of metadata-free datasets to module and component.
+var metadataFreeModules = map[string][2]string{
+	"values.csv":   {"StructureDataset", "ValueTable"},
+	"forms.csv":    {"Wordlist", "FormTable"},
+	"entries.csv":  {"Dictionary", "EntryTable"},
+	"examples.csv": {"TextCorpus", "ExampleTable"},
+}
+
+// listSeparators maps list-valued CLDF properties to the separators used in metadata-free datasets.
+var listSeparators = map[string]string{
+	"source":       ";",
+	"segments":     " ",
+	"alignment":    " ",
+	"analyzedWord": "\t",
+	"gloss":        "\t",
+}
+
+/*
+Discover locates the CLDF dataset at p and returns it. p may be
+
+  - the path of a metadata file,
+  - a directory containing a metadata file named like "<Module>-metadata.json", conforming to a CLDF module,
+  - a single CSV file - or a directory containing one - for a metadata-free dataset,
+  - a directory with a subdirectory cldf containing any of the above, as in the repositories
+    of CLDF datasets,
+  - a zip archive containing any of the above.
+
+Metadata-free datasets are described by metadata inferred from the CSV file: The module is
+determined from the file name (e.g. values.csv for a StructureDataset, forms.csv for a Wordlist)
+and columns are assigned CLDF properties based on their names (e.g. Language_ID for
+languageReference).
+
+Files in zip archives are read directly from the archive, i.e. the dataset's MetadataPath is
+a path into the archive, e.g. "dataset.zip/cldf/StructureDataset-metadata.json".
+*/
+func Discover(p string, bibtexFieldsets ...string) (*Dataset, error) {
+	info, err := os.Stat(p)
+	if err != nil {
+		if strings.HasSuffix(p, ".csv") && pathutil.PathExists(p+".zip") {
+			return newMetadataFreeDataset(p, bibtexFieldsets...)
+		}
+		return nil, err
+	}
+	switch {
+	case info.IsDir():
+		return discoverInDir(p, bibtexFieldsets...)
+	case strings.HasSuffix(p, ".csv"):
+		return newMetadataFreeDataset(p, bibtexFieldsets...)
+	case strings.HasSuffix(p, ".csv.zip"):
+		return newMetadataFreeDataset(strings.TrimSuffix(p, ".zip"), bibtexFieldsets...)
+	case strings.HasSuffix(p, ".zip"):
+		return discoverInZip(p, bibtexFieldsets...)
+	}
+	return NewDataset(p, bibtexFieldsets...)
+}
+
+// discoverInZip looks for a dataset in a zip archive, reading the files directly from the archive.
+func discoverInZip(p string, bibtexFieldsets ...string) (*Dataset, error) {
+	ds, err := discoverInDir(p, bibtexFieldsets...)
+	if err != nil {
+		// Archives often contain the dataset directory rather than its content.
+		if root, ok := pathutil.ArchiveRoot(p); ok {
+			ds, err = discoverInDir(filepath.Join(p, root), bibtexFieldsets...)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("no dataset found in %v: %w", p, err)
+	}
+	return ds, nil
+}
+
+// discoverInDir looks for metadata files conforming to a CLDF module in dir. Lacking these, a
+// metadata-free dataset is created for a known data file. Lacking both, the subdirectory cldf
+// is searched.
+func discoverInDir(dir string, bibtexFieldsets ...string) (*Dataset, error) {
+	candidates, err := pathutil.Glob(filepath.Join(dir, "*-metadata.json"))
+	if err != nil {
+		return nil, err
+	}
+	var found []string
+	for _, candidate := range candidates {
+		md, err := jsonutil.ReadObject(candidate)
+		if err != nil {
+			continue
+		}
+		if conformsTo, ok := md["dc:conformsTo"].(string); ok {
+			if module, ok := ontology.Term(conformsTo); ok && ontology.IsModule(module) {
+				found = append(found, candidate)
+			}
+		}
+	}
+	if len(found) > 1 {
+		return nil, fmt.Errorf("multiple CLDF metadata files in %v: %v", dir, strings.Join(found, ", "))
+	}
+	if len(found) == 1 {
+		return NewDataset(found[0], bibtexFieldsets...)
+	}
+	for _, name := range slices.Sorted(maps.Keys(metadataFreeModules)) {
+		p := filepath.Join(dir, name)
+		if pathutil.PathExists(p) || pathutil.PathExists(p+".zip") {
+			return newMetadataFreeDataset(p, bibtexFieldsets...)
+		}
+	}
+	if sub := filepath.Join(dir, "cldf"); pathutil.PathExists(sub) {
+		return discoverInDir(sub, bibtexFieldsets...)
+	}
+	return nil, fmt.Errorf("no CLDF dataset found in %v", dir)
+}
+
+// newMetadataFreeDataset creates a dataset for the CSV file at p, with metadata inferred from
+// file and column names. A BibTeX file sources.bib in the same directory is used as sources.
+func newMetadataFreeDataset(p string, bibtexFieldsets ...string) (*Dataset, error) {
+	header, err := readHeader(p)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		module    = "Generic"
+		component string
+		dir       = filepath.Dir(p)
+		ns        = ontology.Namespace()
+		columns   = make([]any, len(header))
+		table     = map[string]any{"url": filepath.Base(p)}
+		schema    = map[string]any{}
+	)
+	if mc, ok := metadataFreeModules[filepath.Base(p)]; ok {
+		module, component = mc[0], mc[1]
+		table["dc:conformsTo"] = ns + component
+	}
+	for i, name := range header {
+		col := map[string]any{"name": name}
+		if prop := propertyName(name); ontology.IsProperty(prop) {
+			col["propertyUrl"] = ns + prop
+			if sep, ok := listSeparators[prop]; ok {
+				col["separator"] = sep
+			}
+		}
+		columns[i] = col
+	}
+	schema["columns"] = columns
+	if slices.Contains(header, "ID") {
+		schema["primaryKey"] = []any{"ID"}
+	}
+	table["tableSchema"] = schema
+	metadata := map[string]any{
+		"@context":      "http://www.w3.org/ns/csvw",
+		"dc:conformsTo": ns + module,
+		"tables":        []any{table},
+	}
+	if pathutil.PathExists(filepath.Join(dir, "sources.bib")) || pathutil.PathExists(filepath.Join(dir, "sources.bib.zip")) {
+		metadata["dc:source"] = "sources.bib"
+	}
+	// There is no metadata file, but its path determines the location of the data files.
+	return newDataset(filepath.Join(dir, module+"-metadata.json"), metadata, bibtexFieldsets...)
+}
+
+// readHeader reads the column names from the first row of a CSV file.
+func readHeader(p string) (header []string, err error) {
+	dialect, err := NewDialect(map[string]any{})
+	if err != nil {
+		return nil, err
+	}
+	_, reader, err := pathutil.Reader(p)
+	if err != nil {
+		return nil, err
+	}
+	defer func(r any) {
+		if c, ok := r.(io.Closer); ok {
+			err = errors.Join(err, c.Close())
+		}
+	}(reader)
+	header, err = NewCsvReader(reader.(io.Reader), dialect).Read()
+	if err != nil {
+		return nil, fmt.Errorf("error reading header of %v: %w", p, err)
+	}
+	return header, nil
+}
+
+// propertyName derives the name of a CLDF property from a column name following the naming
+// conventions of pycldf, e.g. "Language_ID" for languageReference or "Primary_Text" for primaryText.
+func propertyName(colName string) string {
+	if colName == "ID" {
+		return "id"
+	}
+	suffix := ""
+	if before, ok := strings.CutSuffix(colName, "_ID"); ok {
+		colName, suffix = before, "Reference"
+	}
+	parts := strings.Split(colName, "_")
+	for i, part := range parts {
+		if i == 0 {
+			parts[i] = lowerInitial(part)
+		} else {
+			parts[i] = strings.ToUpper(part[:min(1, len(part))]) + part[min(1, len(part)):]
+		}
+	}
+	return strings.Join(parts, "") + suffix
+}
+
+// lowerInitial lowercases the leading run of uppercase letters of s, keeping the last one if it
+// starts a word, e.g. "ISO639P3code" becomes "iso639P3code" and "ISOCode" becomes "isoCode".
+func lowerInitial(s string) string {
+	runes := []rune(s)
+	n := 0
+	for n < len(runes) && unicode.IsUpper(runes[n]) {
+		n++
+	}
+	if n > 1 && n < len(runes) && unicode.IsLower(runes[n]) {
+		n--
+	}
+	return strings.ToLower(string(runes[:n])) + string(runes[n:])
+}
@@ -0,0 +1,137 @@
+package cldf
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiscover(t *testing.T) {
+	for _, p := range []string{"testdata", "testdata/StructureDataset-metadata.json"} {
+		ds, err := Discover(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ds.Module != "StructureDataset" || len(ds.Tables) != 4 {
+			t.Errorf(`problem: %v`, p)
+		}
+	}
+	if _, err := Discover("testdata/table_simple.json"); err == nil {
+		t.Errorf(`problem: table metadata is not a dataset`)
+	}
+}
+
+func TestDiscover_metadataFree(t *testing.T) {
+	ds, err := Discover("testdata/values.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ds.Module != "StructureDataset" || ds.Sources == nil {
+		t.Errorf(`problem: %v`, ds.Module)
+	}
+	tbl, ok := ds.Tables["ValueTable"]
+	if !ok {
+		t.Fatal("no ValueTable")
+	}
+	cols := tbl.nameToCol()
+	if cols["Language_ID"].CanonicalName != "cldf_languageReference" || cols["Source"].Separator != ";" {
+		t.Errorf(`problem: %v`, cols["Language_ID"])
+	}
+	if err = ds.LoadData(false); err != nil {
+		t.Fatal(err)
+	}
+	if len(tbl.Data) != 812 {
+		t.Errorf(`problem: %v`, len(tbl.Data))
+	}
+}
+
+// copyFks copies the files of the dataset in testdata/fks to dir - or to a zip archive at dir,
+// if w is given - returning the path of the copied metadata file.
+func copyFks(t *testing.T, dir string, w *zip.Writer) string {
+	for _, name := range []string{"Generic-metadata.json", "langs.csv", "pairs.csv", "refs.csv"} {
+		content, err := os.ReadFile(filepath.Join("testdata", "fks", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if w == nil {
+			if err = os.MkdirAll(dir, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			err = os.WriteFile(filepath.Join(dir, name), content, 0o644)
+		} else {
+			var zf io.Writer
+			if zf, err = w.Create(path.Join(dir, name)); err == nil {
+				_, err = zf.Write(content)
+			}
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return filepath.Join(dir, "Generic-metadata.json")
+}
+
+func TestDiscover_cldfDir(t *testing.T) {
+	dir := t.TempDir()
+	mdPath := copyFks(t, filepath.Join(dir, "cldf"), nil)
+	ds, err := Discover(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ds.MetadataPath != mdPath {
+		t.Errorf(`problem: %v`, ds.MetadataPath)
+	}
+}
+
+func TestDiscover_zip(t *testing.T) {
+	for _, dir := range []string{"fks", "cldf", "repo/cldf"} {
+		archive := filepath.Join(t.TempDir(), "dataset.zip")
+		f, err := os.Create(archive)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := zip.NewWriter(f)
+		mdPath := copyFks(t, dir, w)
+		if err = w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err = f.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		ds, err := Discover(archive)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ds.Module != "Generic" || len(ds.Tables) != 3 {
+			t.Errorf(`problem: %v`, ds.Module)
+		}
+		if err = ds.LoadData(true); err != nil {
+			t.Fatal(err)
+		}
+		if ds.MetadataPath != filepath.Join(archive, filepath.FromSlash(mdPath)) {
+			t.Errorf(`problem: %v`, ds.MetadataPath)
+		}
+		if len(ds.UrlToTable()["langs.csv"].Data) != 2 {
+			t.Errorf(`problem: %v`, ds.UrlToTable()["langs.csv"].Data)
+		}
+	}
+}
+
+func TestPropertyName(t *testing.T) {
+	for colName, expected := range map[string]string{
+		"ID":           "id",
+		"Language_ID":  "languageReference",
+		"Primary_Text": "primaryText",
+		"ISO639P3code": "iso639P3code",
+		"ISOCode":      "isoCode",
+		"Glottocode":   "glottocode",
+	} {
+		if res := propertyName(colName); res != expected {
+			t.Errorf(`problem: %v vs %v`, res, expected)
+		}
+	}
+}
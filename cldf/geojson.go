@@ -0,0 +1,130 @@
+package cldf
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var errCoordinates = errors.New("invalid coordinates")
+
+/*
+GeoJSON returns the languages of the dataset with coordinates as GeoJSON FeatureCollection, with
+one Point feature per language, identified by the language ID.
+
+The values of the LanguageTable columns given in properties - specified by name or CLDF property,
+e.g. "name" or "Macroarea" - are added as feature properties, keyed as specified. If parameter is
+not empty, only languages with values for the parameter with this ID are included, and their
+values - and codes, if values reference codes - are added as properties "value" and "code".
+Multiple values per language are joined with "; ".
+
+Coordinates must be within the valid ranges for latitude (-90 to 90) and longitude (-180 to 180).
+Languages with invalid or only one coordinate are skipped and reported as ValidationError.
+*/
+func (dataset *Dataset) GeoJSON(properties []string, parameter string) (map[string]any, []*ValidationError, error) {
+	tbl, ok := dataset.Tables["LanguageTable"]
+	if !ok {
+		return nil, nil, errors.New("dataset has no LanguageTable")
+	}
+	propCols := make([]*Column, len(properties))
+	for i, prop := range properties {
+		col, err := tbl.column(prop)
+		if err != nil {
+			return nil, nil, err
+		}
+		propCols[i] = col
+	}
+	var values map[string][]*Value
+	if parameter != "" {
+		param, ok := dataset.Parameter(parameter)
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown parameter %v", parameter)
+		}
+		values = make(map[string][]*Value)
+		for _, val := range param.Values() {
+			values[val.LanguageID] = append(values[val.LanguageID], val)
+		}
+	}
+
+	var (
+		features = make([]any, 0, len(tbl.Data))
+		errs     []*ValidationError
+	)
+	for i, row := range tbl.Data {
+		lat, lon := floatValue(row, "cldf_latitude"), floatValue(row, "cldf_longitude")
+		if lat == nil && lon == nil {
+			continue
+		}
+		if err := checkCoordinates(lat, lon); err != nil {
+			errs = append(errs, &ValidationError{
+				Table: tbl.Url,
+				Row:   tbl.rowNumber(i),
+				Value: formatCoordinates(lat, lon),
+				Rule:  "coordinates",
+				Err:   err,
+			})
+			continue
+		}
+		props := make(map[string]any, len(properties)+2)
+		for k, col := range propCols {
+			if row[col.CanonicalName] == nil {
+				continue
+			}
+			val, err := cell{col: col, value: row[col.CanonicalName]}.jsonValue(tbl.Url)
+			if err != nil {
+				return nil, nil, err
+			}
+			props[properties[k]] = val
+		}
+		id := stringValue(row, "cldf_id")
+		if values != nil {
+			vals, ok := values[id]
+			if !ok {
+				continue
+			}
+			var valueStrings, codes []string
+			for _, val := range vals {
+				valueStrings = append(valueStrings, val.Value)
+				if val.CodeID != "" {
+					codes = append(codes, val.CodeID)
+				}
+			}
+			props["value"] = strings.Join(valueStrings, "; ")
+			if len(codes) > 0 {
+				props["code"] = strings.Join(codes, "; ")
+			}
+		}
+		features = append(features, map[string]any{
+			"type": "Feature",
+			"id":   id,
+			"geometry": map[string]any{
+				"type":        "Point",
+				"coordinates": []float64{*lon, *lat},
+			},
+			"properties": props,
+		})
+	}
+	return map[string]any{"type": "FeatureCollection", "features": features}, errs, nil
+}
+
+func checkCoordinates(lat, lon *float64) error {
+	switch {
+	case lat == nil || lon == nil:
+		return fmt.Errorf("%w: latitude and longitude required", errCoordinates)
+	case *lat < -90 || *lat > 90:
+		return fmt.Errorf("%w: latitude out of range", errCoordinates)
+	case *lon < -180 || *lon > 180:
+		return fmt.Errorf("%w: longitude out of range", errCoordinates)
+	}
+	return nil
+}
+
+func formatCoordinates(lat, lon *float64) string {
+	format := func(f *float64) string {
+		if f == nil {
+			return ""
+		}
+		return fmt.Sprint(*f)
+	}
+	return format(lat) + "," + format(lon)
+}
@@ -0,0 +1,56 @@
+package cldf
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDataset_GeoJSON(t *testing.T) {
+	ds, err := GetLoadedDataset("testdata/StructureDataset-metadata.json", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, errs, err := ds.GeoJSON([]string{"name", "Family_name"}, "")
+	if err != nil || len(errs) > 0 {
+		t.Fatal(err, errs)
+	}
+	features := res["features"].([]any)
+	if len(features) != 29 {
+		t.Errorf(`problem: %v`, len(features))
+	}
+	feature := features[0].(map[string]any)
+	expected := map[string]any{"name": "Kharia", "Family_name": "Austroasiatic"}
+	if !reflect.DeepEqual(feature["properties"], expected) {
+		t.Errorf(`problem: %v vs %v`, feature["properties"], expected)
+	}
+	coordinates := feature["geometry"].(map[string]any)["coordinates"].([]float64)
+	if !reflect.DeepEqual(coordinates, []float64{84.3922, 22.3571}) {
+		t.Errorf(`problem: %v`, coordinates)
+	}
+
+	res, _, err = ds.GeoJSON(nil, "B")
+	if err != nil {
+		t.Fatal(err)
+	}
+	feature = res["features"].([]any)[0].(map[string]any)
+	expected = map[string]any{"value": "1", "code": "B-1"}
+	if !reflect.DeepEqual(feature["properties"], expected) {
+		t.Errorf(`problem: %v vs %v`, feature["properties"], expected)
+	}
+	if _, _, err = ds.GeoJSON(nil, "unknown"); err == nil {
+		t.Error(`problem: unknown parameter accepted`)
+	}
+
+	ds.Tables["LanguageTable"].Data[1]["cldf_latitude"] = 95.0
+	res, errs, err = ds.GeoJSON(nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(errs) != 1 || errs[0].Row != 2 || !errors.Is(errs[0], errCoordinates) {
+		t.Errorf(`problem: %v`, errs)
+	}
+	if len(res["features"].([]any)) != 28 {
+		t.Errorf(`problem: %v`, len(res["features"].([]any)))
+	}
+}
@@ -0,0 +1,353 @@
+package cldf
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"strings"
+)
+
+// keyIndex maps the string representation of key values to indexes in Table.Data.
+type keyIndex map[string]int
+
+// rowIndex maps the string representation of key values to row numbers, i.e. to the rows of
+// tables which are checked while streaming their data.
+type rowIndex map[string]int
+
+// keyString returns the string representation of the values of cols in row, or false if any value is null.
+func keyString(row map[string]any, cols []*Column) (string, bool) {
+	vals := make([]string, len(cols))
+	for i, col := range cols {
+		val := row[col.CanonicalName]
+		if val == nil {
+			return "", false
+		}
+		s, err := col.ToString(val)
+		if err != nil {
+			return "", false
+		}
+		vals[i] = s
+	}
+	return strings.Join(vals, "\x00"), true
+}
+
+// displayKey formats a key string for error messages.
+func displayKey(k string) string {
+	return strings.ReplaceAll(k, "\x00", ",")
+}
+
+// columns looks up the columns of tbl with the given names.
+func (tbl *Table) columns(names []string) ([]*Column, error) {
+	nameToCol := tbl.nameToCol()
+	res := make([]*Column, len(names))
+	for i, name := range names {
+		col, ok := nameToCol[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown column %v in table %v", name, tbl.Url)
+		}
+		res[i] = col
+	}
+	return res, nil
+}
+
+// rowNumber returns the number of the row at index i of tbl.Data.
+func (tbl *Table) rowNumber(i int) int {
+	if i < len(tbl.rowNumbers) {
+		return tbl.rowNumbers[i]
+	}
+	return i + 1
+}
+
+// indexRow adds the primary key of a row which is stored at index pos of tbl.Data to the
+// primary key index of the table. Duplicate keys are reported as error.
+func (tbl *Table) indexRow(row map[string]any, number int, pos int) *ValidationError {
+	if len(tbl.PrimaryKey) == 0 {
+		return nil
+	}
+	if tbl.pkIndex == nil {
+		tbl.pkIndex = make(keyIndex)
+	}
+	k, ok := keyString(row, tbl.pkColumns)
+	if !ok {
+		return nil
+	}
+	if first, ok := tbl.pkIndex[k]; ok {
+		return &ValidationError{
+			Table:  tbl.Url,
+			Row:    number,
+			Column: strings.Join(tbl.PrimaryKey, ","),
+			Value:  displayKey(k),
+			Rule:   "primaryKey",
+			Err:    fmt.Errorf("duplicate primary key, first used in row %v", tbl.rowNumber(first))}
+	}
+	tbl.pkIndex[k] = pos
+	return nil
+}
+
+// CheckForeignKeys checks the referential integrity of the loaded data, i.e. whether all foreign
+// keys - including list-valued and composite ones - and all source references can be resolved.
+// Every dangling reference is reported.
+func (dataset *Dataset) CheckForeignKeys() []*ValidationError {
+	checker, errs := newKeyChecker(dataset)
+	for _, tbl := range dataset.Tables {
+		for i, row := range tbl.Data {
+			checker.indexRow(tbl, tbl.rowNumber(i), row)
+		}
+		checker.complete[tbl] = true
+	}
+	for _, tbl := range dataset.Tables {
+		for i, row := range tbl.Data {
+			refErrs, _ := checker.checkRow(tbl, tbl.rowNumber(i), row)
+			errs = append(errs, refErrs...)
+		}
+	}
+	sortValidationErrors(errs)
+	return errs
+}
+
+// foreignKey bundles a foreign key with the columns it relates.
+type foreignKey struct {
+	*ForeignKey
+	name       string
+	cols       []*Column
+	target     *Table
+	targetCols []*Column
+}
+
+// keyChecker checks uniqueness of primary keys and referential integrity of foreign keys using
+// indexes of all referenced keys. Indexes are built row by row, so rows can be checked while
+// streaming the data of a dataset.
+type keyChecker struct {
+	fks      map[*Table][]*foreignKey
+	indexes  map[*Table]map[string]rowIndex // Indexes of referenced columns, keyed by column names
+	complete map[*Table]bool                // Whether the indexes of a table contain all rows
+	sources  map[string]bool
+	deferred []deferredRow // Rows referencing tables which are not complete yet
+}
+
+// newKeyChecker creates a keyChecker for a dataset. Foreign keys which do not relate existing
+// tables and columns are reported as errors.
+func newKeyChecker(dataset *Dataset) (*keyChecker, []*ValidationError) {
+	var (
+		errs       []*ValidationError
+		urlToTable = dataset.UrlToTable()
+		c          = &keyChecker{
+			fks:      make(map[*Table][]*foreignKey),
+			indexes:  make(map[*Table]map[string]rowIndex),
+			complete: make(map[*Table]bool),
+			sources:  make(map[string]bool),
+		}
+	)
+	if dataset.Sources != nil {
+		for _, src := range dataset.Sources.Items {
+			c.sources[src.Id] = true
+		}
+	}
+	for _, tbl := range dataset.Tables {
+		c.indexes[tbl] = make(map[string]rowIndex)
+		if len(tbl.PrimaryKey) > 0 {
+			index := make(rowIndex, len(tbl.pkIndex))
+			// Primary keys of loaded data have been indexed - and checked - already.
+			for k, pos := range tbl.pkIndex {
+				index[k] = tbl.rowNumber(pos)
+			}
+			c.indexes[tbl][strings.Join(tbl.PrimaryKey, ",")] = index
+		}
+	}
+	for _, tbl := range dataset.Tables {
+		for _, fk := range tbl.ForeignKeys {
+			if fk.Reference.Resource == "SourceTable" {
+				continue // Source references are checked separately.
+			}
+			var (
+				err error
+				res = &foreignKey{ForeignKey: fk, name: strings.Join(fk.ColumnReference, ",")}
+				ok  bool
+			)
+			res.target, ok = urlToTable[fk.Reference.Resource]
+			if !ok {
+				err = fmt.Errorf("unknown table %v", fk.Reference.Resource)
+			}
+			if err == nil {
+				res.cols, err = tbl.columns(fk.ColumnReference)
+			}
+			if err == nil {
+				res.targetCols, err = res.target.columns(fk.Reference.ColumnReference)
+			}
+			if err == nil && len(res.cols) != len(res.targetCols) {
+				err = errors.New("number of columns does not match the referenced columns")
+			}
+			if err != nil {
+				errs = append(errs, &ValidationError{Table: tbl.Url, Column: res.name, Rule: "foreignKey", Err: err})
+				continue
+			}
+			c.fks[tbl] = append(c.fks[tbl], res)
+			targetName := strings.Join(fk.Reference.ColumnReference, ",")
+			if _, ok := c.indexes[res.target][targetName]; !ok {
+				c.indexes[res.target][targetName] = make(rowIndex)
+			}
+		}
+	}
+	return c, errs
+}
+
+// indexRow adds a row of tbl to the indexes of the table's referenced keys. Duplicate primary
+// keys are reported as error, unless the data has been loaded and thus checked already.
+func (c *keyChecker) indexRow(tbl *Table, number int, row map[string]any) *ValidationError {
+	pkName := strings.Join(tbl.PrimaryKey, ",")
+	for name, index := range c.indexes[tbl] {
+		if name == pkName && tbl.pkIndex != nil {
+			continue // The primary key index has been built when loading the data.
+		}
+		cols, _ := tbl.columns(strings.Split(name, ","))
+		k, ok := keyString(row, cols)
+		if !ok {
+			continue
+		}
+		if first, ok := index[k]; ok {
+			if name == pkName && len(tbl.PrimaryKey) > 0 {
+				return &ValidationError{
+					Table:  tbl.Url,
+					Row:    number,
+					Column: pkName,
+					Value:  displayKey(k),
+					Rule:   "primaryKey",
+					Err:    fmt.Errorf("duplicate primary key, first used in row %v", first)}
+			}
+			continue
+		}
+		index[k] = number
+	}
+	return nil
+}
+
+// checkRow checks the foreign keys and source references of a row of tbl. References to tables
+// whose indexes are not complete yet, are returned as deferred foreign keys, to be checked later.
+func (c *keyChecker) checkRow(tbl *Table, number int, row map[string]any) (errs []*ValidationError, deferred []*foreignKey) {
+	errs, deferred = c.checkForeignKeys(tbl, number, row, c.fks[tbl])
+	for _, col := range tbl.Columns {
+		if col.CanonicalName != "cldf_source" {
+			continue
+		}
+		refs, _ := row[col.CanonicalName].([]SourceReference)
+		for _, ref := range refs {
+			if !c.sources[ref.Key] {
+				errs = append(errs, &ValidationError{
+					Table:  tbl.Url,
+					Row:    number,
+					Column: col.Name,
+					Value:  ref.String(),
+					Rule:   "source",
+					Err:    fmt.Errorf("unknown source %v", ref.Key)})
+			}
+		}
+	}
+	return errs, deferred
+}
+
+// checkForeignKeys checks the given foreign keys of a row of tbl like checkRow.
+func (c *keyChecker) checkForeignKeys(
+	tbl *Table,
+	number int,
+	row map[string]any,
+	fks []*foreignKey,
+) (errs []*ValidationError, deferred []*foreignKey) {
+	for _, fk := range fks {
+		var vals []string
+		if fk.ManyToMany {
+			vals, _ = row[fk.cols[0].CanonicalName].([]string)
+		} else if k, ok := keyString(row, fk.cols); ok {
+			vals = []string{k}
+		}
+		if len(vals) == 0 {
+			continue
+		}
+		if !c.complete[fk.target] {
+			deferred = append(deferred, fk)
+			continue
+		}
+		index := c.indexes[fk.target][strings.Join(fk.Reference.ColumnReference, ",")]
+		for _, val := range vals {
+			if _, ok := index[val]; !ok {
+				errs = append(errs, &ValidationError{
+					Table:  tbl.Url,
+					Row:    number,
+					Column: fk.name,
+					Value:  displayKey(val),
+					Rule:   "foreignKey",
+					Err:    fmt.Errorf("no matching row in %v", fk.target.Url)})
+			}
+		}
+	}
+	return errs, deferred
+}
+
+// deferredRow holds the referencing values of a row whose foreign keys could not be checked yet.
+type deferredRow struct {
+	tbl    *Table
+	number int
+	data   map[string]any
+	fks    []*foreignKey
+}
+
+// checkDeferred checks the deferred foreign keys of rows whose referenced tables have been
+// completed, keeping the ones still referencing incomplete tables.
+func (c *keyChecker) checkDeferred() (errs []*ValidationError) {
+	var pending []deferredRow
+	for _, row := range c.deferred {
+		rowErrs, fks := c.checkForeignKeys(row.tbl, row.number, row.data, row.fks)
+		errs = append(errs, rowErrs...)
+		if len(fks) > 0 {
+			row.fks = fks
+			pending = append(pending, row)
+		}
+	}
+	c.deferred = pending
+	return errs
+}
+
+// check returns an iterator over rows of tbl which stops with a ValidationError at the first
+// duplicate primary key or dangling reference. References to rows of tables which have not been
+// iterated over completely - e.g. the table itself - are checked once the referenced table is
+// complete, i.e. at the end of the iteration over tbl or of a later table. Iterating over a table
+// a second time does not check the rows again.
+func (c *keyChecker) check(tbl *Table, rows iter.Seq2[*Row, error]) iter.Seq2[*Row, error] {
+	return func(yield func(*Row, error) bool) {
+		if c.complete[tbl] {
+			rows(yield)
+			return
+		}
+		for row, err := range rows {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if verr := c.indexRow(tbl, row.Number, row.Data); verr != nil {
+				yield(nil, verr)
+				return
+			}
+			errs, fks := c.checkRow(tbl, row.Number, row.Data)
+			if len(errs) > 0 {
+				yield(nil, errs[0])
+				return
+			}
+			if len(fks) > 0 {
+				// We only keep the referencing values, to keep memory consumption low.
+				data := make(map[string]any)
+				for _, fk := range fks {
+					for _, col := range fk.cols {
+						data[col.CanonicalName] = row.Data[col.CanonicalName]
+					}
+				}
+				c.deferred = append(c.deferred, deferredRow{tbl, row.Number, data, fks})
+			}
+			if !yield(row, nil) {
+				return
+			}
+		}
+		c.complete[tbl] = true
+		if errs := c.checkDeferred(); len(errs) > 0 {
+			yield(nil, errs[0])
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package cldf
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Get returns the row of a table - specified by component or url - with the given primary key.
+// For composite primary keys, the values of all key columns must be given, in order.
+//
+// Lookup uses the primary key index built when loading the data, so it takes constant time.
+// Like all lookups, Get may be called concurrently, but Data must not be modified once indexed.
+func (dataset *Dataset) Get(table string, key ...string) (map[string]any, bool) {
+	tbl, ok := dataset.table(table)
+	if !ok || len(key) != len(tbl.PrimaryKey) || len(key) == 0 {
+		return nil, false
+	}
+	i, ok := tbl.primaryKeyIndex()[strings.Join(key, "\x00")]
+	if !ok {
+		return nil, false
+	}
+	return tbl.Data[i], true
+}
+
+// Resolve follows the foreign key of a column of a table row - specified by name or canonical
+// name - returning the referenced rows. Values of list-valued columns may reference multiple
+// rows; otherwise at most one row is returned. Missing values yield no rows, dangling references
+// an error.
+func (dataset *Dataset) Resolve(table string, row map[string]any, column string) ([]map[string]any, error) {
+	tbl, ok := dataset.table(table)
+	if !ok {
+		return nil, fmt.Errorf("unknown table %v", table)
+	}
+	col := slices.IndexFunc(tbl.Columns, func(c *Column) bool { return c.Name == column || c.CanonicalName == column })
+	if col < 0 {
+		return nil, fmt.Errorf("unknown column %v in table %v", column, tbl.Url)
+	}
+	for _, fk := range tbl.ForeignKeys {
+		if slices.Contains(fk.ColumnReference, tbl.Columns[col].Name) && fk.Reference.Resource != "SourceTable" {
+			return dataset.resolve(tbl, row, fk)
+		}
+	}
+	return nil, fmt.Errorf("column %v of table %v is not a foreign key", column, tbl.Url)
+}
+
+// References resolves all foreign keys of a table row - except source references - returning
+// the referenced rows keyed by the canonical name of the referencing column. For composite
+// foreign keys, the first column is used as key.
+func (dataset *Dataset) References(table string, row map[string]any) (map[string][]map[string]any, error) {
+	tbl, ok := dataset.table(table)
+	if !ok {
+		return nil, fmt.Errorf("unknown table %v", table)
+	}
+	res := make(map[string][]map[string]any)
+	nameToCol := tbl.nameToCol()
+	for _, fk := range tbl.ForeignKeys {
+		if fk.Reference.Resource == "SourceTable" {
+			continue
+		}
+		rows, err := dataset.resolve(tbl, row, fk)
+		if err != nil {
+			return nil, err
+		}
+		res[nameToCol[fk.ColumnReference[0]].CanonicalName] = rows
+	}
+	return res, nil
+}
+
+func (dataset *Dataset) resolve(tbl *Table, row map[string]any, fk *ForeignKey) ([]map[string]any, error) {
+	target, ok := dataset.UrlToTable()[fk.Reference.Resource]
+	if !ok {
+		return nil, fmt.Errorf("unknown table %v", fk.Reference.Resource)
+	}
+	cols, err := tbl.columns(fk.ColumnReference)
+	if err != nil {
+		return nil, err
+	}
+	index, err := target.index(fk.Reference.ColumnReference)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	if fk.ManyToMany {
+		keys, _ = row[cols[0].CanonicalName].([]string)
+	} else if k, ok := keyString(row, cols); ok {
+		keys = []string{k}
+	}
+	res := make([]map[string]any, 0, len(keys))
+	for _, k := range keys {
+		i, ok := index[k]
+		if !ok {
+			return nil, fmt.Errorf("no row in %v matching %v", target.Url, displayKey(k))
+		}
+		res = append(res, target.Data[i])
+	}
+	return res, nil
+}
+
+// table looks up a table by component or url.
+func (dataset *Dataset) table(name string) (*Table, bool) {
+	if tbl, ok := dataset.Tables[name]; ok {
+		return tbl, true
+	}
+	tbl, ok := dataset.UrlToTable()[name]
+	return tbl, ok
+}
+
+// primaryKeyIndex returns the primary key index of the table, building it if the data has
+// not been loaded via Read or LoadDataWithErrors.
+func (tbl *Table) primaryKeyIndex() keyIndex {
+	tbl.indexLock.Lock()
+	defer tbl.indexLock.Unlock()
+	return tbl.buildPrimaryKeyIndex()
+}
+
+func (tbl *Table) buildPrimaryKeyIndex() keyIndex {
+	if tbl.pkIndex == nil && len(tbl.PrimaryKey) > 0 {
+		tbl.pkIndex = make(keyIndex)
+		for i, row := range tbl.Data {
+			_ = tbl.indexRow(row, tbl.rowNumber(i), i)
+		}
+	}
+	return tbl.pkIndex
+}
+
+// index returns an index mapping the values of the given columns to indexes in Data. Indexes
+// for columns other than the primary key are built on first use.
+func (tbl *Table) index(names []string) (keyIndex, error) {
+	tbl.indexLock.Lock()
+	defer tbl.indexLock.Unlock()
+	if slices.Equal(names, tbl.PrimaryKey) {
+		return tbl.buildPrimaryKeyIndex(), nil
+	}
+	name := strings.Join(names, ",")
+	if index, ok := tbl.indexes[name]; ok {
+		return index, nil
+	}
+	cols, err := tbl.columns(names)
+	if err != nil {
+		return nil, err
+	}
+	index := make(keyIndex, len(tbl.Data))
+	for i, row := range tbl.Data {
+		if k, ok := keyString(row, cols); ok {
+			if _, ok := index[k]; !ok {
+				index[k] = i
+			}
+		}
+	}
+	if tbl.indexes == nil {
+		tbl.indexes = make(map[string]keyIndex)
+	}
+	tbl.indexes[name] = index
+	return index, nil
+}
+
+// resetIndexes discards the indexes built on demand, which must be done when rows are added
+// to Data after lookups.
+func (tbl *Table) resetIndexes() {
+	tbl.indexLock.Lock()
+	defer tbl.indexLock.Unlock()
+	tbl.indexes, tbl.backrefs = nil, nil
+}
@@ -0,0 +1,91 @@
+package cldf
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestDataset_Get(t *testing.T) {
+	ds, err := GetLoadedDataset("testdata/StructureDataset-metadata.json", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lang, ok := ds.Get("LanguageTable", "Santali_NM")
+	if !ok || lang["cldf_name"] != "Santali" {
+		t.Errorf(`problem: %v`, lang)
+	}
+	if _, ok = ds.Get("languages.csv", "Kharia_SM"); !ok {
+		t.Errorf(`problem: lookup by url failed`)
+	}
+	if _, ok = ds.Get("LanguageTable", "unknown"); ok {
+		t.Errorf(`problem: unknown key found`)
+	}
+
+	val, _ := ds.Get("ValueTable", "Santali_NM-2")
+	refs, err := ds.References("ValueTable", val)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(refs["cldf_languageReference"]) != 1 || refs["cldf_languageReference"][0]["cldf_id"] != "Santali_NM" {
+		t.Errorf(`problem: %v`, refs)
+	}
+	if len(refs["cldf_codeReference"]) != 1 || refs["cldf_codeReference"][0]["cldf_id"] != "C-1" {
+		t.Errorf(`problem: %v`, refs)
+	}
+	params, err := ds.Resolve("ValueTable", val, "Parameter_ID")
+	if err != nil || len(params) != 1 || params[0]["cldf_id"] != "C" {
+		t.Errorf(`problem: %v %v`, params, err)
+	}
+	if _, err = ds.Resolve("ValueTable", val, "Value"); err == nil {
+		t.Errorf(`problem: Value is not a foreign key`)
+	}
+}
+
+func TestDataset_Resolve(t *testing.T) {
+	ds := makeDataset("fks/Generic-metadata.json")
+	if err := ds.LoadData(false); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ds.Get("pairs.csv", "l2", "1"); !ok {
+		t.Errorf(`problem: composite key not found`)
+	}
+	row, _ := ds.Get("refs.csv", "r1")
+	langs, err := ds.Resolve("refs.csv", row, "Lang_IDs")
+	if err != nil || len(langs) != 2 || langs[1]["ID"] != "l2" {
+		t.Errorf(`problem: %v %v`, langs, err)
+	}
+	pairs, err := ds.Resolve("refs.csv", row, "Pair_Number")
+	if err != nil || len(pairs) != 1 || pairs[0]["Lang_ID"] != "l1" {
+		t.Errorf(`problem: %v %v`, pairs, err)
+	}
+	parents, err := ds.Resolve("refs.csv", row, "Parent_ID")
+	if err != nil || len(parents) != 0 {
+		t.Errorf(`problem: %v %v`, parents, err)
+	}
+	row, _ = ds.Get("refs.csv", "r2")
+	if _, err = ds.Resolve("refs.csv", row, "Lang_IDs"); err == nil {
+		t.Errorf(`problem: dangling reference not reported`)
+	}
+}
+
+func TestDataset_Get_concurrent(t *testing.T) {
+	ds, err := GetLoadedDataset("testdata/StructureDataset-metadata.json", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			val, _ := ds.Get("ValueTable", "Santali_NM-2")
+			if params, err := ds.Resolve("ValueTable", val, "Parameter_ID"); err != nil || len(params) != 1 {
+				t.Errorf(`problem: %v %v`, params, err)
+			}
+			if len(ds.Languages()[0].Values()) == 0 {
+				t.Errorf(`problem: no values`)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,291 @@
+package cldf
+
+import (
+	"bufio"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// How multiple states of a character for one language are written to NEXUS matrices.
+const (
+	Polymorphic = "polymorphic" // As polymorphism, e.g. (01)
+	FirstState  = "first"       // As the first state, in the order of the ValueTable
+	AsMissing   = "missing"     // As missing data
+)
+
+// nexusSymbols are the symbols used for character states, limiting the number of states.
+const nexusSymbols = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// NexusOptions specifies how a character matrix is built by WriteNexus.
+type NexusOptions struct {
+	Cognates     bool   // Build a presence/absence matrix of cognate sets instead of a matrix of parameters
+	Binarize     bool   // Split parameters into one presence/absence character per code
+	Label        string // LanguageTable column - specified by name or CLDF property - for taxa labels, defaults to "name"
+	Missing      string // The symbol for missing data, defaults to "?"
+	Polymorphism string // How multiple states are written, one of Polymorphic, FirstState and AsMissing
+}
+
+// character is a column of a NEXUS matrix, with the states of the taxa.
+type character struct {
+	label  string
+	states []string   // State labels
+	taxa   [][]string // Indexes of the states of the taxa - as symbols -, nil for missing data
+}
+
+/*
+WriteNexus writes a character matrix of the languages of the dataset as NEXUS file, with a TAXA
+block listing the languages of the LanguageTable and a CHARACTERS block with the matrix.
+
+By default, characters are the parameters of the ValueTable, with states given by the codes of
+the CodeTable - or by the distinct values if a parameter has no codes. With Binarize, each code
+becomes a binary character, coded as present for languages with a value for this code and as
+absent for languages with values for other codes of the parameter. With Cognates, characters are
+the cognate sets of the CognateTable, coded as present for languages with a form in the cognate
+set and as absent for languages with forms for the parameters of the cognate set, but none in the
+set. Languages without data for a character are coded as missing.
+*/
+func (dataset *Dataset) WriteNexus(w io.Writer, opts NexusOptions) error {
+	if opts.Missing == "" {
+		opts.Missing = "?"
+	}
+	if opts.Polymorphism == "" {
+		opts.Polymorphism = Polymorphic
+	}
+	if !slices.Contains([]string{Polymorphic, FirstState, AsMissing}, opts.Polymorphism) {
+		return fmt.Errorf("invalid polymorphism handling %v", opts.Polymorphism)
+	}
+	if opts.Label == "" {
+		opts.Label = "name"
+	}
+	languages := dataset.Languages()
+	if len(languages) == 0 {
+		return errors.New("dataset has no languages")
+	}
+	labels, err := dataset.taxonLabels(languages, opts.Label)
+	if err != nil {
+		return err
+	}
+	taxa := make(map[string]int, len(languages))
+	for i, l := range languages {
+		taxa[l.ID] = i
+	}
+	var chars []*character
+	if opts.Cognates {
+		chars, err = dataset.cognateCharacters(taxa)
+	} else {
+		chars, err = dataset.parameterCharacters(taxa, opts.Binarize)
+	}
+	if err != nil {
+		return err
+	}
+
+	maxStates := 2
+	for _, c := range chars {
+		maxStates = max(maxStates, len(c.states))
+	}
+	// NEXUS symbols are case-insensitive, and "-" denotes gaps.
+	if utf8.RuneCountInString(opts.Missing) != 1 ||
+		strings.ContainsAny(strings.ToUpper(opts.Missing), nexusSymbols[:maxStates]+"-") ||
+		strings.ContainsAny(opts.Missing, " \t\r\n()[]{}/\\,;:=*'\"`<>~") {
+		return fmt.Errorf("invalid symbol for missing data %q", opts.Missing)
+	}
+	out := bufio.NewWriter(w)
+	fmt.Fprintf(out, "#NEXUS\n\nBEGIN TAXA;\n    DIMENSIONS NTAX=%d;\n    TAXLABELS\n", len(labels))
+	for _, label := range labels {
+		fmt.Fprintf(out, "        %v\n", nexusQuote(label))
+	}
+	fmt.Fprintf(out, "    ;\nEND;\n\nBEGIN CHARACTERS;\n    DIMENSIONS NCHAR=%d;\n", len(chars))
+	fmt.Fprintf(out, "    FORMAT DATATYPE=STANDARD MISSING=%v SYMBOLS=\"%v\";\n",
+		opts.Missing, strings.Join(strings.Split(nexusSymbols[:maxStates], ""), " "))
+	out.WriteString("    CHARSTATELABELS\n")
+	for i, c := range chars {
+		states := make([]string, len(c.states))
+		for j, state := range c.states {
+			states[j] = nexusQuote(state)
+		}
+		sep := ","
+		if i == len(chars)-1 {
+			sep = ""
+		}
+		fmt.Fprintf(out, "        %d %v / %v%v\n", i+1, nexusQuote(c.label), strings.Join(states, " "), sep)
+	}
+	out.WriteString("    ;\n    MATRIX\n")
+	width := 0
+	for _, label := range labels {
+		width = max(width, len(nexusQuote(label)))
+	}
+	for i, label := range labels {
+		fmt.Fprintf(out, "        %-*v  ", width, nexusQuote(label))
+		for _, c := range chars {
+			out.WriteString(nexusState(c.taxa[i], opts))
+		}
+		out.WriteString("\n")
+	}
+	out.WriteString("    ;\nEND;\n")
+	return out.Flush()
+}
+
+// taxonLabels returns the values of the label column for the languages, falling back to IDs.
+func (dataset *Dataset) taxonLabels(languages []*Language, label string) ([]string, error) {
+	tbl := dataset.Tables["LanguageTable"]
+	col, err := tbl.column(label)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]string, len(languages))
+	seen := make(map[string]int, len(languages))
+	for i, l := range languages {
+		res[i] = stringValue(l.Row(), col.CanonicalName)
+		if res[i] == "" {
+			res[i] = l.ID
+		}
+		if k, ok := seen[res[i]]; ok {
+			return nil, fmt.Errorf("duplicate taxon label %v for languages %v and %v", res[i], languages[k].ID, l.ID)
+		}
+		seen[res[i]] = i
+	}
+	return res, nil
+}
+
+// parameterCharacters returns the characters for the parameters of the ValueTable.
+func (dataset *Dataset) parameterCharacters(taxa map[string]int, binarize bool) ([]*character, error) {
+	if _, ok := dataset.Tables["ValueTable"]; !ok {
+		return nil, errors.New("dataset has no ValueTable")
+	}
+	var res []*character
+	for _, param := range dataset.Parameters() {
+		var states, codes []string
+		for _, code := range param.Codes() {
+			codes = append(codes, code.ID)
+			states = append(states, cmp.Or(code.Name, code.ID))
+		}
+		values := param.Values()
+		if len(codes) == 0 {
+			for _, val := range values {
+				if val.Value != "" && !slices.Contains(states, val.Value) {
+					states = append(states, val.Value)
+				}
+			}
+			codes = states
+		}
+		// The indexes of the states of each language.
+		languageStates := make([][]int, len(taxa))
+		for _, val := range values {
+			taxon, ok := taxa[val.LanguageID]
+			if !ok {
+				return nil, fmt.Errorf("value %v: unknown language %v", val.ID, val.LanguageID)
+			}
+			state := slices.Index(codes, cmp.Or(val.CodeID, val.Value))
+			if state < 0 && val.CodeID != "" {
+				return nil, fmt.Errorf("value %v: unknown code %v of parameter %v", val.ID, val.CodeID, param.ID)
+			}
+			if state >= 0 && !slices.Contains(languageStates[taxon], state) {
+				languageStates[taxon] = append(languageStates[taxon], state)
+			}
+		}
+		label := cmp.Or(param.Name, param.ID)
+		if binarize {
+			for i, state := range states {
+				c := &character{label: label + ": " + state, states: []string{"absent", "present"}, taxa: make([][]string, len(taxa))}
+				for taxon, lstates := range languageStates {
+					if len(lstates) > 0 {
+						c.taxa[taxon] = []string{"0"}
+						if slices.Contains(lstates, i) {
+							c.taxa[taxon] = []string{"1"}
+						}
+					}
+				}
+				res = append(res, c)
+			}
+			continue
+		}
+		if len(states) > len(nexusSymbols) {
+			return nil, fmt.Errorf("parameter %v has more than %d states", param.ID, len(nexusSymbols))
+		}
+		c := &character{label: label, states: states, taxa: make([][]string, len(taxa))}
+		for taxon, lstates := range languageStates {
+			for _, state := range lstates {
+				c.taxa[taxon] = append(c.taxa[taxon], nexusSymbols[state:state+1])
+			}
+		}
+		res = append(res, c)
+	}
+	return res, nil
+}
+
+// cognateCharacters returns presence/absence characters for the cognate sets of the CognateTable.
+func (dataset *Dataset) cognateCharacters(taxa map[string]int) ([]*character, error) {
+	if _, ok := dataset.Tables["CognateTable"]; !ok {
+		return nil, errors.New("dataset has no CognateTable")
+	}
+	var (
+		cognatesets []string
+		members     = make(map[string][]*Form) // Forms by cognate set
+	)
+	for _, cognate := range dataset.Cognates() {
+		form, ok := cognate.Form()
+		if !ok {
+			return nil, fmt.Errorf("cognate %v: unknown form %v", cognate.ID, cognate.FormID)
+		}
+		if _, ok := members[cognate.CognatesetID]; !ok {
+			cognatesets = append(cognatesets, cognate.CognatesetID)
+		}
+		members[cognate.CognatesetID] = append(members[cognate.CognatesetID], form)
+	}
+	// The languages with forms for each parameter.
+	attested := make(map[string][]bool)
+	for _, form := range dataset.Forms() {
+		taxon, ok := taxa[form.LanguageID]
+		if !ok {
+			return nil, fmt.Errorf("form %v: unknown language %v", form.ID, form.LanguageID)
+		}
+		if _, ok := attested[form.ParameterID]; !ok {
+			attested[form.ParameterID] = make([]bool, len(taxa))
+		}
+		attested[form.ParameterID][taxon] = true
+	}
+	res := make([]*character, len(cognatesets))
+	for i, id := range cognatesets {
+		c := &character{label: id, states: []string{"absent", "present"}, taxa: make([][]string, len(taxa))}
+		for _, form := range members[id] {
+			for taxon, ok := range attested[form.ParameterID] {
+				if ok && c.taxa[taxon] == nil {
+					c.taxa[taxon] = []string{"0"}
+				}
+			}
+		}
+		for _, form := range members[id] {
+			c.taxa[taxa[form.LanguageID]] = []string{"1"}
+		}
+		res[i] = c
+	}
+	return res, nil
+}
+
+// nexusState formats the states of a taxon for a character.
+func nexusState(states []string, opts NexusOptions) string {
+	switch {
+	case len(states) == 0:
+		return opts.Missing
+	case len(states) == 1 || opts.Polymorphism == FirstState:
+		return states[0]
+	case opts.Polymorphism == AsMissing:
+		return opts.Missing
+	}
+	return "(" + strings.Join(states, "") + ")"
+}
+
+// nexusQuote quotes NEXUS words containing whitespace or punctuation.
+func nexusQuote(s string) string {
+	if s != "" && !strings.ContainsFunc(s, func(r rune) bool {
+		return r <= ' ' || strings.ContainsRune("()[]{}/\\,;:=*'\"`+-<>~", r)
+	}) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,132 @@
+package cldf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDataset_WriteNexus(t *testing.T) {
+	ds, err := GetLoadedDataset("testdata/StructureDataset-metadata.json", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := new(bytes.Buffer)
+	if err = ds.WriteNexus(out, NexusOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"DIMENSIONS NTAX=29;",
+		"DIMENSIONS NCHAR=28;",
+		"1 'Gender/Noun classes' / 1 2 3,",
+		"8 '''from'' = ''to''' / 0 1 2,",
+		"        Kharia    0",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf(`problem: %v not in %v`, expected, out.String())
+		}
+	}
+	out.Reset()
+	if err = ds.WriteNexus(out, NexusOptions{Binarize: true, Label: "id"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"'Gender/Noun classes: 1' / absent present,", "Kharia_SM  "} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf(`problem: %v not in %v`, expected, out.String())
+		}
+	}
+	if err = ds.WriteNexus(out, NexusOptions{Cognates: true}); err == nil {
+		t.Error(`problem: cognates matrix without CognateTable`)
+	}
+}
+
+func TestDataset_WriteNexus_cognates(t *testing.T) {
+	b, err := NewBuilder("Wordlist")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, comp := range []string{"LanguageTable", "ParameterTable", "FormTable", "CognateTable"} {
+		if _, err = b.AddComponent(comp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rows := []struct {
+		table string
+		row   map[string]string
+	}{
+		{"LanguageTable", map[string]string{"ID": "l1", "Name": "Lang 1"}},
+		{"LanguageTable", map[string]string{"ID": "l2", "Name": "Lang 2"}},
+		{"LanguageTable", map[string]string{"ID": "l3", "Name": "Lang 3"}},
+		{"ParameterTable", map[string]string{"ID": "hand"}},
+		{"FormTable", map[string]string{"ID": "f1", "Language_ID": "l1", "Parameter_ID": "hand", "Form": "a"}},
+		{"FormTable", map[string]string{"ID": "f2", "Language_ID": "l2", "Parameter_ID": "hand", "Form": "b"}},
+		{"FormTable", map[string]string{"ID": "f3", "Language_ID": "l2", "Parameter_ID": "hand", "Form": "c"}},
+		{"CognateTable", map[string]string{"ID": "c1", "Form_ID": "f1", "Cognateset_ID": "hand-1"}},
+		{"CognateTable", map[string]string{"ID": "c2", "Form_ID": "f2", "Cognateset_ID": "hand-1"}},
+		{"CognateTable", map[string]string{"ID": "c3", "Form_ID": "f3", "Cognateset_ID": "hand-2"}},
+	}
+	for _, r := range rows {
+		if err = b.AddRow(r.table, r.row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ds, err := b.Dataset()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := new(bytes.Buffer)
+	if err = ds.WriteNexus(out, NexusOptions{Cognates: true, Missing: "N"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"'Lang 1'  10\n", "'Lang 2'  11\n", "'Lang 3'  NN\n", "2 'hand-2' / absent present\n"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf(`problem: %q not in %v`, expected, out.String())
+		}
+	}
+	for _, missing := range []string{"-", "1", "??", ";"} {
+		if err = ds.WriteNexus(new(bytes.Buffer), NexusOptions{Cognates: true, Missing: missing}); err == nil {
+			t.Errorf(`problem: %q accepted as missing symbol`, missing)
+		}
+	}
+}
+
+func Test_nexusState(t *testing.T) {
+	for polymorphism, expected := range map[string]string{Polymorphic: "(01)", FirstState: "0", AsMissing: "?"} {
+		if actual := nexusState([]string{"0", "1"}, NexusOptions{Polymorphism: polymorphism, Missing: "?"}); actual != expected {
+			t.Errorf(`problem: %v vs %v`, actual, expected)
+		}
+	}
+}
+
+func TestDataset_WriteNexus_unknownCode(t *testing.T) {
+	b, err := NewBuilder("StructureDataset")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, comp := range []string{"LanguageTable", "ParameterTable", "ValueTable"} {
+		if _, err = b.AddComponent(comp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rows := []struct {
+		table string
+		row   map[string]string
+	}{
+		{"LanguageTable", map[string]string{"ID": "l1"}},
+		{"ParameterTable", map[string]string{"ID": "p1"}},
+		{"ValueTable", map[string]string{"ID": "v1", "Language_ID": "l1", "Parameter_ID": "p1", "Value": "x", "Code_ID": "c1"}},
+	}
+	for _, r := range rows {
+		if err = b.AddRow(r.table, r.row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ds, err := b.Dataset()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ds.WriteNexus(new(bytes.Buffer), NexusOptions{})
+	if err == nil || !strings.Contains(err.Error(), "unknown code c1") {
+		t.Errorf(`problem: %v`, err)
+	}
+}
@@ -0,0 +1,92 @@
+/*
+Package ontology provides access to the CLDF ontology, i.e. the modules, components and properties
+defined in http://cldf.clld.org/v1.0/terms.rdf.
+
+The ontology is embedded in the binary as JSON, listing
+  - the components required by each module,
+  - the properties required by each component,
+  - all properties defined in the ontology.
+
+Terms added in later 1.x versions of CLDF may be missing, so lookups failing for a term do not
+necessarily mean that the term is invalid.
+*/
+package ontology
+
+import (
+	_ "embed"
+	"encoding/json"
+	"maps"
+	"slices"
+	"strings"
+)
+
+//go:embed terms.json
+var termsJson []byte
+
+type terms struct {
+	Namespace  string
+	Modules    map[string][]string
+	Components map[string][]string
+	Properties []string
+}
+
+var cldfTerms terms
+
+func init() {
+	if err := json.Unmarshal(termsJson, &cldfTerms); err != nil {
+		panic(err)
+	}
+}
+
+// Namespace returns the URI of the CLDF ontology.
+func Namespace() string {
+	return cldfTerms.Namespace
+}
+
+// Term returns the local name of a term URI and whether the URI is in the namespace of the
+// embedded version of the CLDF ontology, i.e. http://cldf.clld.org/v1.0/terms.rdf#, which is
+// shared by all 1.x versions of CLDF. URIs of other versions of the ontology are not terms.
+func Term(uri string) (string, bool) {
+	if rest, ok := strings.CutPrefix(uri, "https://"); ok {
+		uri = "http://" + rest
+	}
+	term, ok := strings.CutPrefix(uri, cldfTerms.Namespace)
+	if !ok || term == "" {
+		return "", false
+	}
+	return term, true
+}
+
+// Modules returns the names of all CLDF modules.
+func Modules() []string {
+	return slices.Sorted(maps.Keys(cldfTerms.Modules))
+}
+
+// Components returns the names of all CLDF components.
+func Components() []string {
+	return slices.Sorted(maps.Keys(cldfTerms.Components))
+}
+
+func IsModule(name string) bool {
+	_, ok := cldfTerms.Modules[name]
+	return ok
+}
+
+func IsComponent(name string) bool {
+	_, ok := cldfTerms.Components[name]
+	return ok
+}
+
+func IsProperty(name string) bool {
+	return slices.Contains(cldfTerms.Properties, name)
+}
+
+// RequiredComponents returns the components a dataset of the module must contain.
+func RequiredComponents(module string) []string {
+	return cldfTerms.Modules[module]
+}
+
+// RequiredProperties returns the properties a component must specify columns for.
+func RequiredProperties(component string) []string {
+	return cldfTerms.Components[component]
+}
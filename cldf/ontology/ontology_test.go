@@ -0,0 +1,43 @@
+package ontology
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestOntology(t *testing.T) {
+	if !slices.Contains(Modules(), "StructureDataset") || !slices.Contains(Components(), "ValueTable") {
+		t.Errorf(`problem: %v %v`, Modules(), Components())
+	}
+	if !IsProperty("languageReference") || IsProperty("ValueTable") {
+		t.Errorf(`problem`)
+	}
+	if !slices.Equal(RequiredComponents("Dictionary"), []string{"EntryTable", "SenseTable"}) {
+		t.Errorf(`problem: %v`, RequiredComponents("Dictionary"))
+	}
+	if !slices.Contains(RequiredProperties("FormTable"), "form") {
+		t.Errorf(`problem: %v`, RequiredProperties("FormTable"))
+	}
+	for _, comp := range Components() {
+		for _, prop := range RequiredProperties(comp) {
+			if !IsProperty(prop) {
+				t.Errorf(`problem: unknown property %v required by %v`, prop, comp)
+			}
+		}
+	}
+	if term, ok := Term("http://cldf.clld.org/v1.0/terms.rdf#id"); !ok || term != "id" {
+		t.Errorf(`problem: %v`, term)
+	}
+	if term, ok := Term("https://cldf.clld.org/v1.0/terms.rdf#id"); !ok || term != "id" {
+		t.Errorf(`problem: %v`, term)
+	}
+	for _, uri := range []string{
+		"http://purl.org/dc/terms/title",
+		"http://cldf.clld.org/v2.0/terms.rdf#id",
+		"http://cldf.clld.org/#id",
+	} {
+		if _, ok := Term(uri); ok {
+			t.Errorf(`problem: %v`, uri)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package cldf
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// PivotOptions specifies how the ValueTable is turned into a wide table by Pivot.
+type PivotOptions struct {
+	LanguageLabel  string // LanguageTable column - specified by name or CLDF property - for row labels, defaults to "id"
+	ParameterLabel string // ParameterTable column - specified by name or CLDF property - for column headers, defaults to "id"
+	Codes          bool   // Use code IDs instead of values
+	Separator      string // The string to join multiple values for a language and parameter, defaults to "; "
+}
+
+/*
+Pivot turns the ValueTable into a wide table, with one row per language and one column per
+parameter, returning the header and the rows.
+
+Languages and parameters are listed in the order of the LanguageTable and ParameterTable - or, if
+the dataset lacks these tables, in the order of first reference in the ValueTable - and labeled
+with the values of the columns given in opts, falling back to their IDs. Cells hold the values -
+or code IDs - of the language for the parameter, in the order of the ValueTable, and are empty
+if the language has no value for the parameter.
+
+Labels must be unique - with parameter labels differing from the "Language" header - and values
+must reference languages and parameters of the dataset; otherwise an error is returned.
+*/
+func (dataset *Dataset) Pivot(opts PivotOptions) ([]string, [][]string, error) {
+	opts.LanguageLabel = cmp.Or(opts.LanguageLabel, "id")
+	opts.ParameterLabel = cmp.Or(opts.ParameterLabel, "id")
+	opts.Separator = cmp.Or(opts.Separator, "; ")
+	if _, ok := dataset.Tables["ValueTable"]; !ok {
+		return nil, nil, errors.New("dataset has no ValueTable")
+	}
+	values := dataset.Values()
+	languages, languageLabels, err := dataset.pivotLabels("LanguageTable", opts.LanguageLabel, values,
+		func(v *Value) string { return v.LanguageID }, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	parameters, parameterLabels, err := dataset.pivotLabels("ParameterTable", opts.ParameterLabel, values,
+		func(v *Value) string { return v.ParameterID }, []string{"Language"})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cells := make([][][]string, len(languages))
+	for i := range cells {
+		cells[i] = make([][]string, len(parameters))
+	}
+	var errs []error
+	languageIndex, parameterIndex := indexOf(languages), indexOf(parameters)
+	for _, v := range values {
+		i, ok := languageIndex[v.LanguageID]
+		if !ok {
+			errs = append(errs, fmt.Errorf("value %v references unknown language %v", v.ID, v.LanguageID))
+			continue
+		}
+		j, ok := parameterIndex[v.ParameterID]
+		if !ok {
+			errs = append(errs, fmt.Errorf("value %v references unknown parameter %v", v.ID, v.ParameterID))
+			continue
+		}
+		val := v.Value
+		if opts.Codes {
+			val = v.CodeID
+		}
+		if val != "" {
+			cells[i][j] = append(cells[i][j], val)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, nil, errors.Join(errs...)
+	}
+	header := append([]string{"Language"}, parameterLabels...)
+	rows := make([][]string, len(languages))
+	for i, label := range languageLabels {
+		rows[i] = make([]string, len(parameters)+1)
+		rows[i][0] = label
+		for j, vals := range cells[i] {
+			rows[i][j+1] = strings.Join(vals, opts.Separator)
+		}
+	}
+	return header, rows, nil
+}
+
+// pivotLabels returns the IDs and labels of the rows of a component, or the IDs referenced by
+// values if the dataset has no such component. Labels must be unique and differ from reserved.
+func (dataset *Dataset) pivotLabels(
+	component string,
+	label string,
+	values []*Value,
+	id func(*Value) string,
+	reserved []string,
+) ([]string, []string, error) {
+	tbl, ok := dataset.Tables[component]
+	if !ok {
+		var ids []string
+		seen := make(map[string]bool)
+		for _, v := range values {
+			if !seen[id(v)] {
+				if slices.Contains(reserved, id(v)) {
+					return nil, nil, fmt.Errorf("ID %v of %v clashes with a header", id(v), component)
+				}
+				seen[id(v)] = true
+				ids = append(ids, id(v))
+			}
+		}
+		return ids, ids, nil
+	}
+	col, err := tbl.column(label)
+	if err != nil {
+		return nil, nil, err
+	}
+	ids, labels := make([]string, len(tbl.Data)), make([]string, len(tbl.Data))
+	seen := make(map[string]int, len(tbl.Data))
+	for i, row := range tbl.Data {
+		ids[i] = stringValue(row, "cldf_id")
+		labels[i] = cmp.Or(stringValue(row, col.CanonicalName), ids[i])
+		if k, ok := seen[labels[i]]; ok {
+			return nil, nil, fmt.Errorf("duplicate label %v for %v %v and %v", labels[i], component, ids[k], ids[i])
+		}
+		seen[labels[i]] = i
+		if slices.Contains(reserved, labels[i]) {
+			return nil, nil, fmt.Errorf("label %v for %v %v clashes with a header", labels[i], component, ids[i])
+		}
+	}
+	return ids, labels, nil
+}
+
+// indexOf maps the items of a slice to their index.
+func indexOf(items []string) map[string]int {
+	res := make(map[string]int, len(items))
+	for i, item := range items {
+		res[item] = i
+	}
+	return res
+}
@@ -0,0 +1,105 @@
+package cldf
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDataset_Pivot(t *testing.T) {
+	ds, err := GetLoadedDataset("testdata/StructureDataset-metadata.json", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, rows, err := ds.Pivot(PivotOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(header) != 29 || header[0] != "Language" || header[1] != "B" {
+		t.Errorf(`problem: %v`, header)
+	}
+	if len(rows) != 29 || rows[0][0] != "Kharia_SM" || rows[0][1] != "1" {
+		t.Errorf(`problem: %v`, rows[0])
+	}
+
+	header, rows, err = ds.Pivot(PivotOptions{LanguageLabel: "Name", ParameterLabel: "name", Codes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"Kharia", "B-1", "C-1"}
+	if header[1] != "Gender/Noun classes" || !reflect.DeepEqual(rows[0][:3], expected) {
+		t.Errorf(`problem: %v vs %v`, rows[0][:3], expected)
+	}
+	if _, _, err = ds.Pivot(PivotOptions{LanguageLabel: "unknown"}); err == nil {
+		t.Error(`problem: unknown label column accepted`)
+	}
+}
+
+func TestDataset_Pivot_multipleValues(t *testing.T) {
+	b, err := NewBuilder("StructureDataset")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = b.AddComponent("ValueTable"); err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range []map[string]string{
+		{"ID": "1", "Language_ID": "l1", "Parameter_ID": "p1", "Value": "a"},
+		{"ID": "2", "Language_ID": "l1", "Parameter_ID": "p1", "Value": "b"},
+		{"ID": "3", "Language_ID": "l2", "Parameter_ID": "p2", "Value": "c"},
+	} {
+		if err = b.AddRow("ValueTable", row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ds, err := b.Dataset()
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, rows, err := ds.Pivot(PivotOptions{Separator: "|"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := [][]string{{"Language", "p1", "p2"}, {"l1", "a|b", ""}, {"l2", "", "c"}}
+	if actual := append([][]string{header}, rows...); !reflect.DeepEqual(actual, expected) {
+		t.Errorf(`problem: %v vs %v`, actual, expected)
+	}
+}
+
+func TestDataset_Pivot_invalid(t *testing.T) {
+	b, err := NewBuilder("StructureDataset")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, comp := range []string{"LanguageTable", "ParameterTable", "ValueTable"} {
+		if _, err = b.AddComponent(comp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, r := range []struct {
+		table string
+		row   map[string]string
+	}{
+		{"LanguageTable", map[string]string{"ID": "l1", "Name": "Lang"}},
+		{"LanguageTable", map[string]string{"ID": "l2", "Name": "Lang"}},
+		{"ParameterTable", map[string]string{"ID": "p1", "Name": "Language"}},
+		{"ValueTable", map[string]string{"ID": "1", "Language_ID": "l1", "Parameter_ID": "p1", "Value": "a"}},
+	} {
+		if err = b.AddRow(r.table, r.row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ds, err := b.Dataset()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, opts := range []PivotOptions{{LanguageLabel: "Name"}, {ParameterLabel: "Name"}} {
+		if _, _, err = ds.Pivot(opts); err == nil {
+			t.Errorf(`problem: duplicate labels accepted with %v`, opts)
+		}
+	}
+	ds.Tables["ValueTable"].Data[0]["cldf_languageReference"] = "l9"
+	if _, _, err = ds.Pivot(PivotOptions{}); err == nil || !strings.Contains(err.Error(), "unknown language l9") {
+		t.Errorf(`problem: %v`, err)
+	}
+}
@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"gocldf/internal/pathutil"
 	"io"
-	"os"
+	"maps"
 	"regexp"
 	"slices"
 	"strings"
+	"sync"
+	"unicode"
 
 	"github.com/nickng/bibtex"
 )
@@ -106,6 +108,31 @@ type Sources struct {
 	Path       string
 	Items      []*Source
 	FieldNames []string
+	index      map[string]*Source
+	indexLock  sync.Mutex // Guards building the index on first use
+}
+
+// Get returns the source with the given ID. The sources are indexed by ID on first use, so
+// sources must be added with Add rather than by modifying Items afterwards.
+func (s *Sources) Get(id string) (*Source, bool) {
+	s.indexLock.Lock()
+	defer s.indexLock.Unlock()
+	if s.index == nil {
+		s.index = make(map[string]*Source, len(s.Items))
+		for _, src := range s.Items {
+			s.index[src.Id] = src
+		}
+	}
+	src, ok := s.index[id]
+	return src, ok
+}
+
+// Add appends sources to Items, discarding the index built by Get.
+func (s *Sources) Add(srcs ...*Source) {
+	s.indexLock.Lock()
+	defer s.indexLock.Unlock()
+	s.Items = append(s.Items, srcs...)
+	s.index = nil
 }
 
 func normalizeBibtex(r io.Reader) (io.Reader, error) {
@@ -137,9 +164,8 @@ func NewSources(p string, fieldsets ...string) (sources *Sources, err error) {
 		return nil, err
 	}
 	defer func(file any) {
-		switch file.(type) {
-		case *os.File:
-			err = file.(*os.File).Close()
+		if c, ok := file.(io.Closer); ok {
+			err = c.Close()
 		}
 	}(f)
 
@@ -172,22 +198,36 @@ func NewSources(p string, fieldsets ...string) (sources *Sources, err error) {
 }
 
 func (s *Sources) SqlCreate() string {
-	var res []string
-	res = append(res, "CREATE TABLE IF NOT EXISTS `SourceTable` (")
-	res = append(res, "\t`id`\tTEXT,")
-	res = append(res, "\t`genre`\tTEXT,")
+	return s.sqlCreate(false, SQLite)
+}
+
+// sqlCreate returns the CREATE TABLE statement for the SourceTable. If namespaced is true, the
+// table is shared by multiple datasets and keyed by dataset and ID.
+func (s *Sources) sqlCreate(namespaced bool, d SqlDialect) string {
+	var clauses []string
+	if namespaced {
+		clauses = append(clauses, d.Quote("dataset")+"\tTEXT")
+	}
+	clauses = append(clauses, d.Quote("id")+"\tTEXT", d.Quote("genre")+"\tTEXT")
 	for _, field := range s.FieldNames {
 		if field == "type" || field == "id" {
 			field += "_"
 		}
-		res = append(res, fmt.Sprintf("\t`%s`\tTEXT,", field))
+		clauses = append(clauses, d.Quote(field)+"\tTEXT")
+	}
+	if namespaced {
+		clauses = append(clauses,
+			fmt.Sprintf("PRIMARY KEY(%v)", sqlQuoteAll(d, "dataset", "id")),
+			sqlForeignKey(d, []string{"dataset"}, "dataset", []string{"id"}))
+	} else {
+		clauses = append(clauses, fmt.Sprintf("PRIMARY KEY(%v)", d.Quote("id")))
 	}
-	res = append(res, "\tPRIMARY KEY(`id`)")
-	res = append(res, ");")
-	return strings.Join(res, "\n")
+	return sqlCreateTable(d, "SourceTable", clauses)
 }
 
-func (s *Sources) itemsToSql() (rows [][]any, colNames []string, err error) {
+// itemsToSql returns the rows and column names of the SourceTable. If namespace is not empty, rows
+// are tagged with it in an additional dataset column.
+func (s *Sources) itemsToSql(namespace string) (rows [][]any, colNames []string, err error) {
 	colNames = []string{}
 	rows = make([][]any, len(s.Items))
 	for i, item := range s.Items {
@@ -211,6 +251,161 @@ func (s *Sources) itemsToSql() (rows [][]any, colNames []string, err error) {
 			}
 			rows[i][j+2] = val
 		}
+		if namespace != "" {
+			rows[i] = append(rows[i], namespace)
+		}
+	}
+	if namespace != "" && len(colNames) > 0 {
+		colNames = append(colNames, "dataset")
 	}
 	return rows, colNames, nil
 }
+
+// SourceReference is a reference to a source as used in cldf:source columns, i.e. the key of a
+// BibTeX entry, optionally followed by a context - typically page numbers - in square brackets,
+// e.g. "Meier2005[12-15]".
+//
+// Brackets which are part of the key or the context can be escaped with a backslash. Balanced
+// brackets within the context need no escaping. A context spread over multiple bracketed
+// ranges, e.g. "Meier2005[12][23-24]", is joined with ", " - but formatted with the original
+// brackets as long as key and context are not changed.
+type SourceReference struct {
+	Key     string
+	Context string
+	raw     string // The parsed reference, if its context spans multiple brackets.
+}
+
+// ParseSourceReference parses a formatted source reference.
+func ParseSourceReference(s string) (SourceReference, error) {
+	var (
+		ref     SourceReference
+		key     strings.Builder
+		context []string
+		current strings.Builder
+		depth   int
+		escaped bool
+		inKey   = true
+	)
+	s = strings.TrimSpace(s)
+	for _, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+			if inKey {
+				key.WriteRune(r)
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\\':
+			escaped = true
+		case inKey && r == '[':
+			inKey = false
+			depth = 1
+		case inKey && r == ']':
+			return ref, fmt.Errorf("ill-formatted source reference %q: unbalanced brackets", s)
+		case inKey:
+			key.WriteRune(r)
+		case depth == 0 && r == '[':
+			depth = 1
+		case depth == 0:
+			if !unicode.IsSpace(r) {
+				return ref, fmt.Errorf("ill-formatted source reference %q: text after context", s)
+			}
+		case r == '[':
+			depth++
+			current.WriteRune(r)
+		case r == ']':
+			depth--
+			if depth == 0 {
+				context = append(context, strings.TrimSpace(current.String()))
+				current.Reset()
+			} else {
+				current.WriteRune(r)
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if escaped {
+		// A trailing backslash is taken literally.
+		if inKey {
+			key.WriteRune('\\')
+		} else {
+			current.WriteRune('\\')
+		}
+	}
+	if depth != 0 {
+		return ref, fmt.Errorf("ill-formatted source reference %q: unbalanced brackets", s)
+	}
+	ref.Key = strings.TrimSpace(key.String())
+	if ref.Key == "" {
+		return ref, fmt.Errorf("ill-formatted source reference %q: missing key", s)
+	}
+	ref.Context = strings.Join(context, ", ")
+	if len(context) > 1 {
+		ref.raw = s
+	}
+	return ref, nil
+}
+
+// String formats the reference, escaping brackets where necessary.
+func (ref SourceReference) String() string {
+	if ref.raw != "" {
+		if parsed, err := ParseSourceReference(ref.raw); err == nil && parsed.Key == ref.Key && parsed.Context == ref.Context {
+			return ref.raw
+		}
+	}
+	res := strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`).Replace(ref.Key)
+	if ref.Context == "" {
+		return res
+	}
+	context := strings.ReplaceAll(ref.Context, `\`, `\\`)
+	if !balancedBrackets(context) {
+		context = strings.NewReplacer("[", `\[`, "]", `\]`).Replace(context)
+	}
+	return res + "[" + context + "]"
+}
+
+func balancedBrackets(s string) bool {
+	depth := 0
+	for _, r := range s {
+		switch r {
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth < 0 {
+				return false
+			}
+		}
+	}
+	return depth == 0
+}
+
+// parseSourceReferences parses the items of a list-valued cldf:source cell.
+func parseSourceReferences(vals []string) ([]SourceReference, error) {
+	res := make([]SourceReference, len(vals))
+	for i, val := range vals {
+		ref, err := ParseSourceReference(val)
+		if err != nil {
+			return make([]SourceReference, 0), err
+		}
+		res[i] = ref
+	}
+	return res, nil
+}
+
+// Write writes the sources in BibTeX format.
+func (s *Sources) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, item := range s.Items {
+		fields := make([]string, 0, len(item.Fields))
+		for _, name := range slices.Sorted(maps.Keys(item.Fields)) {
+			fields = append(fields, fmt.Sprintf("    %v = {%v}", name, item.Fields[name]))
+		}
+		if _, err := fmt.Fprintf(bw, "@%v{%v,\n%v\n}\n\n", item.Type, item.Id, strings.Join(fields, ",\n")); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,69 @@
+package cldf
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestParseSourceReference(t *testing.T) {
+	tests := []struct {
+		input   string
+		key     string
+		context string
+		str     string
+	}{
+		{"Meier2005", "Meier2005", "", "Meier2005"},
+		{" Meier2005[12-15] ", "Meier2005", "12-15", "Meier2005[12-15]"},
+		{"Meier2005[12][23-24]", "Meier2005", "12, 23-24", "Meier2005[12][23-24]"},
+		{"Meier2005[12] [23-24]", "Meier2005", "12, 23-24", "Meier2005[12] [23-24]"},
+		{"Meier2005[p. 12 [fig. 3]]", "Meier2005", "p. 12 [fig. 3]", "Meier2005[p. 12 [fig. 3]]"},
+		{`Meier2005[12\]]`, "Meier2005", "12]", `Meier2005[12\]]`},
+		{`Mei\[er\]2005`, "Mei[er]2005", "", `Mei\[er\]2005`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			ref, err := ParseSourceReference(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ref.Key != tt.key || ref.Context != tt.context {
+				t.Errorf(`problem: %q vs %q`, ref, tt)
+			}
+			if ref.String() != tt.str {
+				t.Errorf(`problem: %q vs %q`, ref.String(), tt.str)
+			}
+		})
+	}
+	ref, _ := ParseSourceReference("Meier2005[12][23-24]")
+	ref.Context = "13"
+	if ref.String() != "Meier2005[13]" {
+		t.Errorf(`problem: %q`, ref.String())
+	}
+	for _, input := range []string{"", "[12]", "Meier2005[12", "Meier2005]", "Meier2005[12]x"} {
+		if _, err := ParseSourceReference(input); err == nil {
+			t.Errorf(`problem: %q should be invalid`, input)
+		}
+	}
+}
+
+func TestSources_Get(t *testing.T) {
+	sources := &Sources{Items: []*Source{{Id: "a"}, {Id: "b"}, {Id: "a"}}}
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, ok := sources.Get("b"); !ok {
+				t.Errorf(`problem: source not found`)
+			}
+		}()
+	}
+	wg.Wait()
+	if _, ok := sources.Get("c"); ok {
+		t.Errorf(`problem: unknown source found`)
+	}
+	sources.Add(&Source{Id: "c"})
+	if _, ok := sources.Get("c"); !ok {
+		t.Errorf(`problem: added source not found`)
+	}
+}
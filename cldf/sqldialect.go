@@ -0,0 +1,178 @@
+package cldf
+
+import (
+	"fmt"
+	"gocldf/cldf/datatype"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SqlDialect abstracts the differences between the SQL syntax of database engines in the
+// statements created for a dataset, i.e. quoting of identifiers, column types and the
+// representation of values.
+type SqlDialect interface {
+	// Quote quotes an identifier, e.g. a table or column name.
+	Quote(identifier string) string
+	// ColumnType returns the column type for values of a datatype.
+	ColumnType(dt *datatype.Datatype) string
+	// ToSql converts the Go object of a datatype into a value suitable for insertion, i.e. nil,
+	// a bool, an int, a float64 or a string.
+	ToSql(dt *datatype.Datatype, val any) (any, error)
+	// OnDelete returns the action for rows referencing deleted rows, e.g. "CASCADE", or the
+	// empty string if the dialect does not support actions.
+	OnDelete() string
+}
+
+// The supported SQL dialects.
+var (
+	SQLite     SqlDialect = sqliteDialect{}
+	PostgreSQL SqlDialect = standardDialect{types: postgresTypes, onDelete: "CASCADE"}
+	DuckDB     SqlDialect = standardDialect{types: duckdbTypes}
+)
+
+// SqlDialects maps names of SQL dialects to their implementation.
+var SqlDialects = map[string]SqlDialect{
+	"sqlite":   SQLite,
+	"postgres": PostgreSQL,
+	"duckdb":   DuckDB,
+}
+
+// sqliteDialect is the dialect used to load datasets into SQLite databases, with values
+// converted by the datatypes' ToSql.
+type sqliteDialect struct{}
+
+func (sqliteDialect) Quote(identifier string) string {
+	return "`" + strings.ReplaceAll(identifier, "`", "``") + "`"
+}
+
+func (sqliteDialect) ColumnType(dt *datatype.Datatype) string {
+	return dt.SqlType()
+}
+
+func (sqliteDialect) ToSql(dt *datatype.Datatype, val any) (any, error) {
+	return dt.ToSql(val)
+}
+
+func (sqliteDialect) OnDelete() string {
+	return "CASCADE"
+}
+
+// standardDialect is a dialect following standard SQL, i.e. quoting identifiers with double quotes
+// and storing booleans and temporal values in columns of the respective types.
+type standardDialect struct {
+	types    map[string]string // Column types by datatype base, defaulting to TEXT
+	onDelete string
+}
+
+var postgresTypes = map[string]string{
+	"boolean":       "BOOLEAN",
+	"integer":       "BIGINT",
+	"int":           "BIGINT",
+	"decimal":       "NUMERIC",
+	"number":        "NUMERIC",
+	"float":         "DOUBLE PRECISION",
+	"double":        "DOUBLE PRECISION",
+	"json":          "JSONB",
+	"time":          "TIME",
+	"date":          "DATE",
+	"datetime":      "TIMESTAMP",
+	"dateTime":      "TIMESTAMP",
+	"dateTimeStamp": "TIMESTAMPTZ",
+}
+
+var duckdbTypes = map[string]string{
+	"boolean":       "BOOLEAN",
+	"integer":       "BIGINT",
+	"int":           "BIGINT",
+	"decimal":       "DOUBLE",
+	"number":        "DOUBLE",
+	"float":         "DOUBLE",
+	"double":        "DOUBLE",
+	"json":          "JSON",
+	"time":          "TIME",
+	"date":          "DATE",
+	"datetime":      "TIMESTAMP",
+	"dateTime":      "TIMESTAMP",
+	"dateTimeStamp": "TIMESTAMPTZ",
+}
+
+func (standardDialect) Quote(identifier string) string {
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
+func (d standardDialect) ColumnType(dt *datatype.Datatype) string {
+	if t, ok := d.types[dt.Base]; ok {
+		return t
+	}
+	return "TEXT"
+}
+
+func (d standardDialect) ToSql(dt *datatype.Datatype, val any) (any, error) {
+	switch v := val.(type) {
+	case bool:
+		return v, nil
+	case time.Time:
+		switch d.ColumnType(dt) {
+		case "DATE":
+			return v.Format(time.DateOnly), nil
+		case "TIME":
+			return v.Format("15:04:05.999999999"), nil
+		case "TIMESTAMP":
+			return v.Format("2006-01-02 15:04:05.999999999"), nil
+		}
+		return v.Format(time.RFC3339Nano), nil
+	}
+	return dt.ToSql(val)
+}
+
+func (d standardDialect) OnDelete() string {
+	return d.onDelete
+}
+
+// sqlLiteral formats a value as returned by SqlDialect.ToSql as SQL literal.
+func sqlLiteral(val any) string {
+	switch v := val.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return sqlLiteral(strconv.FormatFloat(v, 'g', -1, 64))
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case string:
+		return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	}
+	return sqlLiteral(fmt.Sprint(val))
+}
+
+// sqlCreateTable returns a CREATE TABLE statement with the given column definitions and constraints.
+func sqlCreateTable(d SqlDialect, name string, clauses []string) string {
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %v (\n\t%v\n);", d.Quote(name), strings.Join(clauses, ",\n\t"))
+}
+
+// sqlForeignKey returns a FOREIGN KEY constraint of a CREATE TABLE statement.
+func sqlForeignKey(d SqlDialect, cols []string, table string, tcols []string) string {
+	res := fmt.Sprintf("FOREIGN KEY(%v) REFERENCES %v(%v)", sqlQuoteAll(d, cols...), d.Quote(table), sqlQuoteAll(d, tcols...))
+	if action := d.OnDelete(); action != "" {
+		res += " ON DELETE " + action
+	}
+	return res
+}
+
+// sqlQuoteAll quotes a list of identifiers, joining them with commas.
+func sqlQuoteAll(d SqlDialect, identifiers ...string) string {
+	quoted := make([]string, len(identifiers))
+	for i, identifier := range identifiers {
+		quoted[i] = d.Quote(identifier)
+	}
+	return strings.Join(quoted, ",")
+}
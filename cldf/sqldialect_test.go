@@ -0,0 +1,125 @@
+package cldf
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"gocldf/cldf/datatype"
+	"gocldf/internal/dbutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSqlDialect_ColumnType(t *testing.T) {
+	var tests = []struct {
+		base     string
+		dialect  SqlDialect
+		expected string
+	}{
+		{"boolean", SQLite, "INTEGER"},
+		{"boolean", PostgreSQL, "BOOLEAN"},
+		{"decimal", PostgreSQL, "NUMERIC"},
+		{"date", PostgreSQL, "DATE"},
+		{"dateTimeStamp", PostgreSQL, "TIMESTAMPTZ"},
+		{"json", PostgreSQL, "JSONB"},
+		{"anyURI", PostgreSQL, "TEXT"},
+		{"json", DuckDB, "JSON"},
+		{"double", DuckDB, "DOUBLE"},
+	}
+	for _, tt := range tests {
+		t.Run("ColumnType", func(t *testing.T) {
+			dt, err := datatype.New(map[string]any{"datatype": tt.base})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual := tt.dialect.ColumnType(dt); actual != tt.expected {
+				t.Errorf(`problem: %v vs %v`, actual, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSqlDialect_ToSql(t *testing.T) {
+	var tests = []struct {
+		datatype map[string]any
+		input    string
+		dialect  SqlDialect
+		expected string
+	}{
+		{map[string]any{"base": "boolean", "format": "yes|no"}, "yes", SQLite, "1"},
+		{map[string]any{"base": "boolean", "format": "yes|no"}, "yes", PostgreSQL, "TRUE"},
+		{map[string]any{"base": "date", "format": "dd.MM.yyyy"}, "10.12.2018", SQLite, "'10.12.2018'"},
+		{map[string]any{"base": "date", "format": "dd.MM.yyyy"}, "10.12.2018", PostgreSQL, "'2018-12-10'"},
+		{map[string]any{"base": "datetime"}, "2018-12-10T20:20:20", DuckDB, "'2018-12-10 20:20:20'"},
+		{map[string]any{"base": "string"}, "it's", PostgreSQL, "'it''s'"},
+	}
+	for _, tt := range tests {
+		t.Run("ToSql", func(t *testing.T) {
+			dt, err := datatype.New(map[string]any{"datatype": tt.datatype})
+			if err != nil {
+				t.Fatal(err)
+			}
+			val, err := dt.ToGo(tt.input, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			val, err = tt.dialect.ToSql(dt, val)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual := sqlLiteral(val); actual != tt.expected {
+				t.Errorf(`problem: %v vs %v`, actual, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDataset_WriteSql(t *testing.T) {
+	for _, tt := range []struct {
+		dialect  SqlDialect
+		expected []string
+	}{
+		{PostgreSQL, []string{
+			`CREATE TABLE IF NOT EXISTS "ValueTable" (`,
+			`"cldf_latitude"	NUMERIC CHECK("cldf_latitude" >= -90 AND "cldf_latitude" <= 90)`,
+			`FOREIGN KEY("cldf_languageReference") REFERENCES "LanguageTable"("cldf_id") ON DELETE CASCADE`,
+			`('Kharia_SM','Kharia','Eurasia',22.3571,84.3922,'khar1287','khr','Austroasiatic')`,
+		}},
+		{DuckDB, []string{
+			`FOREIGN KEY("cldf_languageReference") REFERENCES "LanguageTable"("cldf_id"),`,
+		}},
+	} {
+		var out bytes.Buffer
+		err := makeDataset("StructureDataset-metadata.json").WriteSql(context.Background(), &out, tt.dialect, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, expected := range tt.expected {
+			if !strings.Contains(out.String(), expected) {
+				t.Errorf(`problem: "%q"" not in "%q""`, expected, out.String()[:1000])
+			}
+		}
+	}
+}
+
+func TestDataset_WriteSql_sqlite(t *testing.T) {
+	var out bytes.Buffer
+	err := makeDataset("StructureDataset-metadata.json").WriteSql(context.Background(), &out, SQLite, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var count int
+	err = dbutil.WithDatabase(filepath.Join(t.TempDir(), "db.sqlite"), func(db *sql.DB) error {
+		if _, err := db.Exec(out.String()); err != nil {
+			return err
+		}
+		return db.QueryRow("SELECT count(*) FROM ValueTable_SourceTable;").Scan(&count)
+	}, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 813 {
+		t.Errorf(`problem: %v vs %v`, count, 813)
+	}
+}
@@ -0,0 +1,56 @@
+package cldf
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// sqlDumpBatchSize is the maximal number of rows inserted with one INSERT statement in SQL scripts.
+const sqlDumpBatchSize = 500
+
+/*
+WriteSql writes a SQL script in the SQL dialect d which creates the tables of the dataset - see
+StreamToSql - and inserts the data, wrapped in a transaction.
+
+Table data is read from the CSV files while writing, so the dataset does not need to be loaded.
+Since no database is involved, writing SQL scripts does not require a SQLite driver.
+*/
+func (dataset *Dataset) WriteSql(ctx context.Context, w io.Writer, d SqlDialect, noChecks bool) error {
+	schema, tableRows, err := dataset.StreamToSql(ctx, noChecks, d)
+	if err != nil {
+		return err
+	}
+	out := bufio.NewWriter(w)
+	fmt.Fprintf(out, "BEGIN TRANSACTION;\n%v\n", schema)
+	for _, tRows := range tableRows {
+		insert := fmt.Sprintf("INSERT INTO %v (%v) VALUES\n", d.Quote(tRows.TableName), sqlQuoteAll(d, tRows.ColNames...))
+		n := 0
+		for row, err := range tRows.Rows {
+			if err != nil {
+				return err
+			}
+			if n == 0 {
+				out.WriteString(insert)
+			} else {
+				out.WriteString(",\n")
+			}
+			values := make([]string, len(row))
+			for i, val := range row {
+				values[i] = sqlLiteral(val)
+			}
+			fmt.Fprintf(out, "(%v)", strings.Join(values, ","))
+			if n++; n == sqlDumpBatchSize {
+				out.WriteString(";\n")
+				n = 0
+			}
+		}
+		if n > 0 {
+			out.WriteString(";\n")
+		}
+	}
+	out.WriteString("COMMIT;\n")
+	return out.Flush()
+}
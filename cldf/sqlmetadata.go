@@ -0,0 +1,423 @@
+package cldf
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gocldf/internal/jsonutil"
+	"iter"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+/*
+The metadata of datasets loaded into a SQLite database is stored in bookkeeping tables, keyed by
+the Namespace of the dataset - the empty string for databases holding only one dataset:
+
+  - cldf_metadata holds the name of the metadata file, the dialect and the metadata of the dataset
+    - without the table descriptions - as JSON.
+  - cldf_tables holds the SQL names, URLs, dialects and JSON descriptions - without columns - of the
+    tables of the dataset, in the order of the metadata.
+  - cldf_columns holds the original names, CLDF properties, datatypes, separators and JSON
+    descriptions of the columns of the tables, in the order of the table schemas.
+
+Together with the data, this allows FromSqlite to reconstruct the dataset.
+*/
+
+// sqlCreateBookkeeping returns the CREATE TABLE statements for the bookkeeping tables.
+func sqlCreateBookkeeping(d SqlDialect) string {
+	text := func(names ...string) []string {
+		res := make([]string, len(names))
+		for i, name := range names {
+			res[i] = d.Quote(name) + "\tTEXT"
+		}
+		return res
+	}
+	integer := d.Quote("position") + "\tINTEGER"
+	return strings.Join([]string{
+		sqlCreateTable(d, "cldf_metadata", append(
+			text("dataset", "metadata_file", "dialect", "metadata"),
+			fmt.Sprintf("PRIMARY KEY(%v)", d.Quote("dataset")))),
+		sqlCreateTable(d, "cldf_tables", append(
+			slices.Insert(text("dataset", "table_name", "url", "dialect", "metadata"), 3, integer),
+			fmt.Sprintf("PRIMARY KEY(%v)", sqlQuoteAll(d, "dataset", "table_name")),
+			sqlForeignKey(d, []string{"dataset"}, "cldf_metadata", []string{"dataset"}))),
+		sqlCreateTable(d, "cldf_columns", append(
+			slices.Insert(text("dataset", "table_name", "name", "canonical_name", "property_url", "datatype", "separator", "metadata"), 2, integer),
+			fmt.Sprintf("PRIMARY KEY(%v)", sqlQuoteAll(d, "dataset", "table_name", "position")),
+			sqlForeignKey(d, []string{"dataset", "table_name"}, "cldf_tables", []string{"dataset", "table_name"}))),
+	}, "\n")
+}
+
+// bookkeepingRows returns the rows of the bookkeeping tables for the dataset.
+func (dataset *Dataset) bookkeepingRows() ([]TableRows, error) {
+	var tableRows, columnRows [][]any
+	metadata, err := jsonString(dataset.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	dialect, err := jsonString(dataset.Metadata["dialect"])
+	if err != nil {
+		return nil, err
+	}
+	for i, tbl := range dataset.orderedByMetadata() {
+		tableSchema, _ := tbl.metadata["tableSchema"].(map[string]any)
+		jsonCols, _ := tableSchema["columns"].([]any)
+		// The table description is stored without columns, which are stored in cldf_columns.
+		description := maps.Clone(tbl.metadata)
+		description["tableSchema"] = maps.Clone(tableSchema)
+		delete(description["tableSchema"].(map[string]any), "columns")
+		tblMetadata, err := jsonString(description)
+		if err != nil {
+			return nil, err
+		}
+		tblDialect, err := jsonString(tbl.metadata["dialect"])
+		if err != nil {
+			return nil, err
+		}
+		name := dataset.SqlTableName(tbl)
+		tableRows = append(tableRows, []any{dataset.Namespace, name, tbl.Url, i + 1, tblDialect, tblMetadata})
+		for j, col := range tbl.Columns {
+			jsonCol, _ := jsonCols[j].(map[string]any)
+			colMetadata, err := jsonString(jsonCol)
+			if err != nil {
+				return nil, err
+			}
+			dt, err := jsonString(jsonCol["datatype"])
+			if err != nil {
+				return nil, err
+			}
+			columnRows = append(columnRows, []any{
+				dataset.Namespace, name, j + 1, col.Name, col.CanonicalName,
+				nullString(col.PropertyUrl.String()), dt, nullString(col.Separator), colMetadata})
+		}
+	}
+	return []TableRows{
+		{"cldf_metadata", []string{"dataset", "metadata_file", "dialect", "metadata"}, sliceRows([][]any{
+			{dataset.Namespace, filepath.Base(dataset.MetadataPath), dialect, metadata}})},
+		{"cldf_tables", []string{"dataset", "table_name", "url", "position", "dialect", "metadata"}, sliceRows(tableRows)},
+		{"cldf_columns", []string{
+			"dataset", "table_name", "position", "name", "canonical_name", "property_url", "datatype", "separator", "metadata"},
+			sliceRows(columnRows)},
+	}, nil
+}
+
+/*
+FromSqlite reconstructs a dataset from a SQLite database created by loading the dataset with its
+bookkeeping tables, e.g. with createdb. The dataset is read from the tables with the given
+namespace; if namespace is empty and the database holds only one dataset, this dataset is read.
+
+The metadata is taken from the bookkeeping tables, with the metadata file located in dir. The data
+is read from the tables of the dataset - including edits made in the database -, re-joining the
+rows of association tables into list-valued columns, and the sources from the SourceTable.
+*/
+func FromSqlite(db *sql.DB, namespace string, dir string) (*Dataset, error) {
+	if namespace == "" {
+		var namespaces []string
+		err := queryRows(db, "SELECT `dataset` FROM `cldf_metadata` ORDER BY `dataset`;", func(vals []any) error {
+			namespaces = append(namespaces, sqlString(vals[0]))
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("database has no bookkeeping tables: %w", err)
+		}
+		switch {
+		case len(namespaces) == 0:
+			return nil, errors.New("database holds no dataset")
+		case len(namespaces) > 1:
+			return nil, fmt.Errorf("database holds multiple datasets, one of %v must be specified", namespaces)
+		}
+		namespace = namespaces[0]
+	}
+	var metadataFile, metadata string
+	err := db.QueryRow(
+		"SELECT `metadata_file`, `metadata` FROM `cldf_metadata` WHERE `dataset` = ?;", namespace,
+	).Scan(&metadataFile, &metadata)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("no dataset %v in database", namespace)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var result map[string]any
+	if err = json.Unmarshal([]byte(metadata), &result); err != nil {
+		return nil, err
+	}
+	var tableNames, tables []any
+	err = queryRows(db, "SELECT `table_name`, `metadata` FROM `cldf_tables` WHERE `dataset` = ? ORDER BY `position`;",
+		func(vals []any) error {
+			var description map[string]any
+			if err := json.Unmarshal([]byte(sqlString(vals[1])), &description); err != nil {
+				return err
+			}
+			tableNames, tables = append(tableNames, sqlString(vals[0])), append(tables, description)
+			return nil
+		}, namespace)
+	if err != nil {
+		return nil, err
+	}
+	for i, description := range tables {
+		var columns []any
+		err := queryRows(db,
+			"SELECT `metadata` FROM `cldf_columns` WHERE `dataset` = ? AND `table_name` = ? ORDER BY `position`;",
+			func(vals []any) error {
+				var col map[string]any
+				if err := json.Unmarshal([]byte(sqlString(vals[0])), &col); err != nil {
+					return err
+				}
+				columns = append(columns, col)
+				return nil
+			}, namespace, tableNames[i])
+		if err != nil {
+			return nil, err
+		}
+		tableSchema, _ := description.(map[string]any)["tableSchema"].(map[string]any)
+		if tableSchema == nil {
+			tableSchema = make(map[string]any)
+			description.(map[string]any)["tableSchema"] = tableSchema
+		}
+		tableSchema["columns"] = columns
+	}
+	result["tables"] = tables
+
+	var sources *Sources
+	sourcesBibtex, err := jsonutil.GetString(result, "dc:source", "")
+	if err != nil {
+		return nil, err
+	}
+	if sourcesBibtex != "" {
+		if sources, err = sourcesFromSqlite(db, namespace); err != nil {
+			return nil, err
+		}
+		sources.Path = filepath.Join(dir, sourcesBibtex)
+	}
+	ds, err := datasetWithSources(filepath.Join(dir, metadataFile), result, sources)
+	if err != nil {
+		return nil, err
+	}
+	ds.Namespace = namespace
+	urlToTable := ds.UrlToTable()
+	for _, tbl := range ds.orderedByMetadata() {
+		if err = tbl.fromSqlite(db, namespace, urlToTable); err != nil {
+			return nil, fmt.Errorf("error reading %v: %w", ds.SqlTableName(tbl), err)
+		}
+	}
+	return ds, nil
+}
+
+// fromSqlite reads the data of the table from a SQLite database, in the order of insertion.
+func (tbl *Table) fromSqlite(db *sql.DB, namespace string, UrlToTable map[string]*Table) error {
+	colNames, _ := tbl.rowConverter(false, SQLite)
+	cols := make([]*Column, len(colNames))
+	query := make([]string, len(colNames))
+	for i, name := range colNames {
+		cols[i] = tbl.Columns[slices.IndexFunc(tbl.Columns, func(col *Column) bool { return col.CanonicalName == name })]
+		query[i] = fmt.Sprintf("`%v`", name)
+	}
+	tbl.Data = tbl.Data[:0]
+	err := queryRows(db, fmt.Sprintf(
+		"SELECT %v FROM `%v` ORDER BY rowid;", strings.Join(query, ","), sqlTableName(namespace, tbl.CanonicalName)),
+		func(vals []any) error {
+			row := make(map[string]any, len(tbl.Columns))
+			for i, col := range cols {
+				val, err := col.fromSql(vals[i])
+				if err != nil {
+					return fmt.Errorf("column %v: %w", col.Name, err)
+				}
+				row[col.CanonicalName] = val
+			}
+			tbl.Data = append(tbl.Data, row)
+			return nil
+		})
+	if err != nil {
+		return err
+	}
+
+	for _, fk := range tbl.ManyToMany() {
+		if len(tbl.PrimaryKey) == 0 {
+			return fmt.Errorf("list-valued foreign key %v in table without primary key", fk.ColumnReference[0])
+		}
+		spk := tbl.nameToCol()[tbl.PrimaryKey[0]].CanonicalName
+		tableName, assocCols, _ := tbl.associationRowConverter(fk, UrlToTable, namespace)
+		var (
+			colName = "cldf_source"
+			where   string
+			args    []any
+		)
+		if fk.Reference.Resource != "SourceTable" {
+			colName = tbl.nameToCol()[fk.ColumnReference[0]].CanonicalName
+			// List-valued columns referencing the same table share an association table.
+			where, args = " WHERE `context` = ?", []any{colName}
+		}
+		refs := make(map[string][]SourceReference)
+		err := queryRows(db, fmt.Sprintf(
+			"SELECT `%v`, `%v`, `context` FROM `%v`%v ORDER BY rowid;", assocCols[0], assocCols[1], tableName, where),
+			func(vals []any) error {
+				id := sqlString(vals[0])
+				refs[id] = append(refs[id], SourceReference{Key: sqlString(vals[1]), Context: sqlString(vals[2])})
+				return nil
+			}, args...)
+		if err != nil {
+			return err
+		}
+		for _, row := range tbl.Data {
+			items := refs[sqlString(row[spk])]
+			if colName == "cldf_source" {
+				row[colName] = append(make([]SourceReference, 0, len(items)), items...)
+				continue
+			}
+			// For other association tables the context is the column name.
+			ids := make([]string, len(items))
+			for i, item := range items {
+				ids[i] = item.Key
+			}
+			row[colName] = ids
+		}
+	}
+	return nil
+}
+
+// fromSql converts a value read from a SQLite database back into the value of a cell. Values of
+// list-valued columns are stored joined with the separator.
+func (column *Column) fromSql(x any) (any, error) {
+	if column.Separator == "" {
+		return column.Datatype.FromSql(x)
+	}
+	val, err := column.ToGo(sqlString(x), true, true)
+	if vals, ok := val.([]string); ok && err == nil && column.CanonicalName == "cldf_source" {
+		return parseSourceReferences(vals)
+	}
+	return val, err
+}
+
+// sourcesFromSqlite reads the sources of the dataset with the given namespace from the SourceTable.
+func sourcesFromSqlite(db *sql.DB, namespace string) (*Sources, error) {
+	var (
+		query = "SELECT * FROM `SourceTable` ORDER BY rowid;"
+		args  []any
+	)
+	if namespace != "" {
+		query = "SELECT * FROM `SourceTable` WHERE `dataset` = ? ORDER BY rowid;"
+		args = append(args, namespace)
+	}
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	colNames, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	res := &Sources{}
+	for rows.Next() {
+		vals := make([]any, len(colNames))
+		ptrs := make([]any, len(colNames))
+		for i := range vals {
+			ptrs[i] = &vals[i]
+		}
+		if err = rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		src := &Source{Fields: make(map[string]string)}
+		for i, name := range colNames {
+			val := sqlString(vals[i])
+			switch name {
+			case "id":
+				src.Id = val
+			case "genre":
+				src.Type = val
+			case "dataset":
+			default:
+				if val == "" {
+					continue
+				}
+				// Fields clashing with the id and genre columns are stored with an underscore appended.
+				if name == "id_" || name == "type_" {
+					name = strings.TrimSuffix(name, "_")
+				}
+				src.Fields[name] = val
+				if !slices.Contains(res.FieldNames, name) {
+					res.FieldNames = append(res.FieldNames, name)
+				}
+			}
+		}
+		res.Add(src)
+	}
+	return res, rows.Err()
+}
+
+// queryRows runs a query, calling fn with the values of each row of the result.
+func queryRows(db *sql.DB, query string, fn func([]any) error, args ...any) error {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	colNames, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		vals := make([]any, len(colNames))
+		ptrs := make([]any, len(colNames))
+		for i := range vals {
+			ptrs[i] = &vals[i]
+		}
+		if err = rows.Scan(ptrs...); err != nil {
+			return err
+		}
+		if err = fn(vals); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// sliceRows returns an iterator over rows held in memory.
+func sliceRows(rows [][]any) iter.Seq2[[]any, error] {
+	return func(yield func([]any, error) bool) {
+		for _, row := range rows {
+			if !yield(row, nil) {
+				return
+			}
+		}
+	}
+}
+
+// jsonString serializes a JSON value, returning nil for nil values, i.e. NULL in SQL.
+func jsonString(val any) (any, error) {
+	if val == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// nullString returns nil for the empty string, i.e. NULL in SQL.
+func nullString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// sqlString formats a value read from a SQLite database as string, returning the empty string
+// for NULL.
+func sqlString(val any) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	}
+	return fmt.Sprint(val)
+}
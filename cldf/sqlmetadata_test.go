@@ -0,0 +1,184 @@
+package cldf
+
+import (
+	"context"
+	"database/sql"
+	"gocldf/internal/dbutil"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func loadSqlite(t *testing.T, ds *Dataset, dbPath string) {
+	err := dbutil.WithDatabase(dbPath, func(db *sql.DB) error {
+		return dbutil.WithTransaction(db, func(tx *sql.Tx) error {
+			schema, tableRows, err := ds.StreamToSqlite(context.Background(), false)
+			if err != nil {
+				return err
+			}
+			if _, err = tx.Exec(schema); err != nil {
+				return err
+			}
+			for _, tRows := range tableRows {
+				if err = dbutil.BatchInsertSeq(tx, tRows.TableName, tRows.ColNames, tRows.Rows); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFromSqlite(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "db.sqlite")
+	ds := makeDataset("StructureDataset-metadata.json")
+	ds.Namespace = "ns"
+	loadSqlite(t, ds, dbPath)
+
+	var res *Dataset
+	err := dbutil.WithDatabase(dbPath, func(db *sql.DB) (err error) {
+		_, err = db.Exec("UPDATE ns_ValueTable_SourceTable SET context = '12' WHERE ValueTable_cldf_id = 'Kharia_SM-1';")
+		if err != nil {
+			return err
+		}
+		res, err = FromSqlite(db, "", filepath.Join(dir, "out"))
+		return err
+	}, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.MetadataPath != filepath.Join(dir, "out", "StructureDataset-metadata.json") || res.Namespace != "ns" {
+		t.Errorf(`problem: %v %v`, res.MetadataPath, res.Namespace)
+	}
+	if res.Module != "StructureDataset" || !slices.Equal(res.order, ds.order) {
+		t.Errorf(`problem: %v %v`, res.Module, res.order)
+	}
+	if err = ds.LoadData(false); err != nil {
+		t.Fatal(err)
+	}
+	for name, tbl := range ds.Tables {
+		if len(res.Tables[name].Data) != len(tbl.Data) {
+			t.Errorf(`problem: %v %v vs. %v`, name, len(res.Tables[name].Data), len(tbl.Data))
+		}
+	}
+	val, ok := res.Value("Kharia_SM-1")
+	if !ok || len(val.SourceReferences) != 1 || val.SourceReferences[0].String() != "Peterson2017[12]" {
+		t.Errorf(`problem: %v`, val)
+	}
+	if len(res.Sources.Items) != len(ds.Sources.Items) {
+		t.Errorf(`problem: %v`, res.Sources.Items)
+	}
+	if src, ok := res.Sources.Get("Peterson2017"); !ok || src.Fields["doi"] != "10.1515/jsall-2017-0008" {
+		t.Errorf(`problem: %v`, src)
+	}
+}
+
+func TestFromSqlite_sharedAssociationTable(t *testing.T) {
+	b, err := NewBuilder("Generic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = b.AddComponent("LanguageTable"); err != nil {
+		t.Fatal(err)
+	}
+	// Two list-valued columns referencing the LanguageTable share the association table.
+	items, err := b.AddTable("items.csv",
+		map[string]any{"name": "ID", "propertyUrl": "http://cldf.clld.org/v1.0/terms.rdf#id"},
+		map[string]any{"name": "Speakers", "separator": ";"},
+		map[string]any{"name": "Neighbours", "separator": ";"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fks []any
+	for _, col := range []string{"Speakers", "Neighbours"} {
+		fks = append(fks, map[string]any{
+			"columnReference": []any{col},
+			"reference":       map[string]any{"resource": "languages.csv", "columnReference": []any{"ID"}},
+		})
+	}
+	items.metadata["tableSchema"].(map[string]any)["foreignKeys"] = fks
+	for _, r := range []struct {
+		table string
+		row   map[string]string
+	}{
+		{"LanguageTable", map[string]string{"ID": "l1"}},
+		{"LanguageTable", map[string]string{"ID": "l2"}},
+		{"LanguageTable", map[string]string{"ID": "l3"}},
+		{"items.csv", map[string]string{"ID": "i1", "Speakers": "l1;l2", "Neighbours": "l3"}},
+	} {
+		if err = b.AddRow(r.table, r.row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	built, err := b.Dataset()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err = built.Write(filepath.Join(dir, "ds")); err != nil {
+		t.Fatal(err)
+	}
+	ds, err := NewDataset(filepath.Join(dir, "ds", "Generic-metadata.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dbPath := filepath.Join(dir, "db.sqlite")
+	loadSqlite(t, ds, dbPath)
+
+	var res *Dataset
+	err = dbutil.WithDatabase(dbPath, func(db *sql.DB) (err error) {
+		res, err = FromSqlite(db, "", filepath.Join(dir, "out"))
+		return err
+	}, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := res.Tables["items.csv"].Data[0]
+	if !slices.Equal(row["Speakers"].([]string), []string{"l1", "l2"}) ||
+		!slices.Equal(row["Neighbours"].([]string), []string{"l3"}) {
+		t.Errorf(`problem: %v`, row)
+	}
+}
+
+func TestFromSqlite_noPrimaryKey(t *testing.T) {
+	b, err := NewBuilder("Generic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = b.AddTable("items.csv", map[string]any{"name": "Name"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = b.AddRow("items.csv", map[string]string{"Name": "x"}); err != nil {
+		t.Fatal(err)
+	}
+	built, err := b.Dataset()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err = built.Write(filepath.Join(dir, "ds")); err != nil {
+		t.Fatal(err)
+	}
+	ds, err := NewDataset(filepath.Join(dir, "ds", "Generic-metadata.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dbPath := filepath.Join(dir, "db.sqlite")
+	loadSqlite(t, ds, dbPath)
+
+	var res *Dataset
+	err = dbutil.WithDatabase(dbPath, func(db *sql.DB) (err error) {
+		res, err = FromSqlite(db, "", filepath.Join(dir, "out"))
+		return err
+	}, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data := res.Tables["items.csv"].Data; len(data) != 1 || data[0]["Name"] != "x" {
+		t.Errorf(`problem: %v`, data)
+	}
+}
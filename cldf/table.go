@@ -1,18 +1,18 @@
 package cldf
 
 import (
-	"encoding/csv"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"gocldf/internal/jsonutil"
 	"gocldf/internal/pathutil"
 	"io"
-	"os"
+	"iter"
 	"path/filepath"
 	"slices"
 	"strings"
-	"unicode"
+	"sync"
 )
 
 type Reference struct {
@@ -35,13 +35,19 @@ type Table struct {
 	Data          []map[string]interface{}
 	ForeignKeys   []*ForeignKey
 	Dialect       *Dialect
-	trimmer       func(string) string
+	rowNumbers    []int               // Row numbers of Data, if rows have been skipped when loading.
+	lines         []int               // Lines of the CSV file at which the rows of Data start, if read from a file.
+	pkIndex       keyIndex            // Maps primary keys to indexes in Data.
+	indexes       map[string]keyIndex // Indexes of other referenced columns, built on demand.
+	backrefs      map[string]map[string][]int
+	indexLock     *sync.Mutex // Guards building the indexes on demand
+	pkColumns     []*Column
+	metadata      map[string]any // The JSON description of the table
 }
 
 func NewTable(jsonTable map[string]interface{}, withSourceTable bool) (tbl *Table, err error) {
 	var (
 		dialect *Dialect
-		trimmer = func(s string) string { return s }
 		fks     []*ForeignKey
 	)
 	tableSchema := jsonTable["tableSchema"].(map[string]interface{})
@@ -53,6 +59,34 @@ func NewTable(jsonTable map[string]interface{}, withSourceTable bool) (tbl *Tabl
 		if err != nil {
 			return nil, err
 		}
+		columns[i] = col
+	}
+	// URI templates not specified for a column are inherited from the table schema or the table.
+	for _, key := range []string{"aboutUrl", "propertyUrl", "valueUrl"} {
+		var inherited *URITemplate
+		for _, description := range []map[string]any{jsonTable, tableSchema} {
+			if s, ok := description[key].(string); ok {
+				if inherited, err = ParseURITemplate(s); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if inherited == nil {
+			continue
+		}
+		for _, col := range columns {
+			switch {
+			case key == "aboutUrl" && col.AboutUrl == nil:
+				col.AboutUrl = inherited
+			case key == "propertyUrl" && col.PropertyUrl == nil:
+				col.PropertyUrl = inherited
+				col.CanonicalName = col.canonicalName()
+			case key == "valueUrl" && col.ValueUrl == nil:
+				col.ValueUrl = inherited
+			}
+		}
+	}
+	for _, col := range columns {
 		if withSourceTable && col.CanonicalName == "cldf_source" {
 			// remember and store additional foreign key constraint!
 			fks = append(
@@ -62,7 +96,6 @@ func NewTable(jsonTable map[string]interface{}, withSourceTable bool) (tbl *Tabl
 					ColumnReference: []string{"cldf_source"},
 					Reference:       Reference{ColumnReference: []string{"id"}, Resource: "SourceTable"}})
 		}
-		columns[i] = col
 	}
 	listValued := make(map[string]bool, len(columns))
 	for _, col := range columns {
@@ -103,15 +136,6 @@ func NewTable(jsonTable map[string]interface{}, withSourceTable bool) (tbl *Tabl
 	} else {
 		dialect = nil
 	}
-	if dialect != nil {
-		if dialect.trim == "true" {
-			trimmer = strings.TrimSpace
-		} else if dialect.trim == "end" {
-			trimmer = func(s string) string {
-				return strings.TrimRightFunc(s, unicode.IsSpace)
-			}
-		}
-	}
 	res := &Table{
 		Url:         jsonTable["url"].(string),
 		Columns:     columns,
@@ -119,7 +143,11 @@ func NewTable(jsonTable map[string]interface{}, withSourceTable bool) (tbl *Tabl
 		ForeignKeys: fks,
 		PrimaryKey:  pk,
 		Dialect:     dialect,
-		trimmer:     trimmer,
+		metadata:    jsonTable,
+		indexLock:   &sync.Mutex{},
+	}
+	if res.pkColumns, err = res.columns(pk); err != nil {
+		return nil, fmt.Errorf("invalid primary key: %w", err)
 	}
 	res.Comp, err = jsonutil.GetString(jsonTable, "dc:conformsTo", "")
 	if err != nil {
@@ -135,16 +163,36 @@ func NewTable(jsonTable map[string]interface{}, withSourceTable bool) (tbl *Tabl
 }
 
 // Read a row represented as slice of strings into Go objects.
-func (tbl *Table) readRow(fields []string, noChecks bool) (map[string]interface{}, error) {
+//
+// All invalid cells of the row are reported. Invalid cells are set to nil - or an empty
+// list for list-valued columns. Items of list-valued source columns are parsed into
+// SourceReference values.
+func (tbl *Table) readRow(fields []string, number int, noChecks bool) (map[string]interface{}, []*ValidationError) {
+	if len(fields) != len(tbl.Columns) {
+		return nil, []*ValidationError{{
+			Table: tbl.Url,
+			Row:   number,
+			Rule:  "cell count",
+			Err:   fmt.Errorf("expected %v cells, got %v", len(tbl.Columns), len(fields))}}
+	}
+	var errs []*ValidationError
 	row := make(map[string]interface{}, len(fields))
-	for i := 0; i < len(fields); i++ {
-		val, err := tbl.Columns[i].ToGo(tbl.trimmer(fields[i]), true, noChecks)
+	for i, col := range tbl.Columns {
+		val, err := col.ToGo(fields[i], true, noChecks)
+		if vals, ok := val.([]string); ok && err == nil && col.CanonicalName == "cldf_source" {
+			val, err = parseSourceReferences(vals)
+		}
 		if err != nil {
-			return nil, err
+			errs = append(errs, newCellError(tbl, number, col, fields[i], err))
+			if col.Separator != "" && col.CanonicalName == "cldf_source" {
+				val = make([]SourceReference, 0)
+			} else if col.Separator != "" {
+				val = make([]string, 0)
+			}
 		}
-		row[tbl.Columns[i].CanonicalName] = val
+		row[col.CanonicalName] = val
 	}
-	return row, nil
+	return row, errs
 }
 
 type TableRead struct {
@@ -152,47 +200,147 @@ type TableRead struct {
 	Err error
 }
 
-func (tbl *Table) Read(dir string, dialect *Dialect, noChecks bool, ch chan<- TableRead) {
-	var reader *csv.Reader
-	fp := filepath.Join(dir, tbl.Url)
-	var (
-		rows [][]string
-		err  error
-	)
-	_, r, err := pathutil.Reader(fp)
-	if err != nil {
-		ch <- TableRead{tbl.Url, err}
-		return
-	}
-	defer func(file any) {
-		switch file.(type) {
-		case *os.File:
-			err = file.(*os.File).Close()
+// Row is a row of a table read into Go objects, keyed by the canonical names of the columns.
+type Row struct {
+	Number int // The 1-based number of the row, not counting header rows.
+	Line   int // The line of the CSV file at which the row starts.
+	Data   map[string]interface{}
+	Errors []*ValidationError // Only populated when reading with RowsWithErrors.
+}
+
+// Rows returns an iterator over the rows of the table, read from the table's file in dir.
+//
+// Rows are read and converted one at a time, so iterating over a table does not require
+// holding its data in memory. Iteration stops at the first error, which is yielded with
+// a nil Row. Invalid data is reported as *ValidationError. Cancelling ctx stops the
+// iteration with ctx.Err().
+func (tbl *Table) Rows(ctx context.Context, dir string, dialect *Dialect, noChecks bool) iter.Seq2[*Row, error] {
+	return tbl.rows(ctx, dir, dialect, noChecks, false)
+}
+
+// RowsWithErrors returns an iterator over the rows of the table like Rows, but does not stop
+// at invalid data. Instead, rows are yielded with all their validation errors in Row.Errors.
+// Rows without the expected number of cells are yielded with nil Data.
+func (tbl *Table) RowsWithErrors(ctx context.Context, dir string, dialect *Dialect) iter.Seq2[*Row, error] {
+	return tbl.rows(ctx, dir, dialect, false, true)
+}
+
+func (tbl *Table) rows(ctx context.Context, dir string, dialect *Dialect, noChecks bool, collect bool) iter.Seq2[*Row, error] {
+	return func(yield func(*Row, error) bool) {
+		_, r, err := pathutil.Reader(filepath.Join(dir, tbl.Url))
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		defer func(file any) {
+			if c, ok := file.(io.Closer); ok {
+				c.Close()
+			}
+		}(r)
+		if tbl.Dialect != nil {
+			dialect = tbl.Dialect
 		}
-	}(r)
-	reader = csv.NewReader(r.(io.Reader))
+		reader := NewCsvReader(r.(io.Reader), dialect)
 
-	if tbl.Dialect != nil {
-		dialect = tbl.Dialect
+		number := 0
+		for rowIndex := 0; ; rowIndex++ {
+			if err := ctx.Err(); err != nil {
+				yield(nil, err)
+				return
+			}
+			fields, err := reader.Read()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if rowIndex < dialect.headerRowCount {
+				continue
+			}
+			if len(fields) == 1 && fields[0] == "" {
+				// A blank row which isn't skipped is a row of empty cells.
+				fields = make([]string, len(tbl.Columns))
+			}
+			number++
+			val, errs := tbl.readRow(fields, number, noChecks)
+			if len(errs) > 0 && !collect {
+				yield(nil, errs[0])
+				return
+			}
+			if !yield(&Row{Number: number, Line: reader.Line(), Data: val, Errors: errs}, nil) {
+				return
+			}
+		}
 	}
-	dialect.ConfigureCsvReader(reader)
-	rows, err = reader.ReadAll()
-	if err != nil {
-		ch <- TableRead{tbl.Url, err}
-		return
+}
+
+// dataRows returns an iterator over the rows already loaded into tbl.Data.
+func (tbl *Table) dataRows() iter.Seq2[*Row, error] {
+	return func(yield func(*Row, error) bool) {
+		for i, row := range tbl.Data {
+			if !yield(&Row{Number: tbl.rowNumber(i), Data: row}, nil) {
+				return
+			}
+		}
 	}
+}
 
-	for rowIndex, row := range rows {
-		if !dialect.header || (rowIndex > 0) { // FIXME: take headerRowCount and skipRows into account!
-			val, err := tbl.readRow(row, noChecks)
+func (tbl *Table) Read(dir string, dialect *Dialect, noChecks bool, ch chan<- TableRead) {
+	for row, err := range tbl.Rows(context.Background(), dir, dialect, noChecks) {
+		if err != nil {
+			ch <- TableRead{tbl.Url, err}
+			return
+		}
+		tbl.Data = append(tbl.Data, row.Data)
+		tbl.lines = append(tbl.lines, row.Line)
+		if verr := tbl.indexRow(row.Data, row.Number, len(tbl.Data)-1); verr != nil && !noChecks {
+			ch <- TableRead{tbl.Url, verr}
+			return
+		}
+	}
+	ch <- TableRead{tbl.Url, nil}
+}
+
+// TemplateVariables returns the values of a row as variables for the expansion of the URI
+// templates of the table's columns, keyed by column name. Values are formatted as strings, or
+// slices of strings for list-valued columns. As is common in CLDF metadata, values of columns
+// with CLDF properties can also be referenced by property name, e.g. "{glottocode}".
+func (tbl *Table) TemplateVariables(row map[string]any) map[string]any {
+	res := make(map[string]any, len(tbl.Columns))
+	for _, col := range tbl.Columns {
+		val := row[col.CanonicalName]
+		if val == nil {
+			continue
+		}
+		var v any = listItems(val)
+		if col.Separator == "" {
+			s, err := col.ToString(val)
 			if err != nil {
-				ch <- TableRead{tbl.Url, err}
-				return
+				continue
+			}
+			v = s
+		}
+		res[col.Name] = v
+		if property, ok := strings.CutPrefix(col.CanonicalName, "cldf_"); ok {
+			if _, ok := res[property]; !ok {
+				res[property] = v
 			}
-			tbl.Data = append(tbl.Data, val)
 		}
 	}
-	ch <- TableRead{tbl.Url, err}
+	return res
+}
+
+// column looks up a column by name or CLDF property, e.g. "Name" or "name".
+func (tbl *Table) column(name string) (*Column, error) {
+	j := slices.IndexFunc(tbl.Columns, func(col *Column) bool {
+		return col.Name == name || col.CanonicalName == "cldf_"+name
+	})
+	if j < 0 {
+		return nil, fmt.Errorf("unknown column %v in table %v", name, tbl.Url)
+	}
+	return tbl.Columns[j], nil
 }
 
 func (tbl *Table) nameToCol() map[string]*Column {
@@ -203,11 +351,15 @@ func (tbl *Table) nameToCol() map[string]*Column {
 	return nameToCol
 }
 
-func (tbl *Table) sqlCreateAssociationTable(fk ForeignKey, UrlToTable map[string]*Table) string {
+// sqlCreateAssociationTable returns the CREATE TABLE statement for the association table of the
+// many-to-many foreign key fk. If namespace is not empty, the names of the tables of the dataset are
+// prefixed with it and the association table for sources references the shared SourceTable.
+func (tbl *Table) sqlCreateAssociationTable(fk ForeignKey, UrlToTable map[string]*Table, namespace string, d SqlDialect) string {
 	var (
-		res    []string
-		ttable string
-		tpk    string
+		ttable  string
+		tpk     string
+		tname   string
+		sources bool
 	)
 	stable := tbl.CanonicalName
 	spk := tbl.nameToCol()[tbl.PrimaryKey[0]].CanonicalName
@@ -215,6 +367,8 @@ func (tbl *Table) sqlCreateAssociationTable(fk ForeignKey, UrlToTable map[string
 	if fk.Reference.Resource == "SourceTable" {
 		ttable = "SourceTable"
 		tpk = "id"
+		tname = ttable
+		sources = namespace != ""
 	} else {
 		ttable_, ok := UrlToTable[fk.Reference.Resource]
 		if !ok {
@@ -222,29 +376,49 @@ func (tbl *Table) sqlCreateAssociationTable(fk ForeignKey, UrlToTable map[string
 		}
 		ttable = ttable_.CanonicalName
 		tpk = ttable_.nameToCol()[ttable_.PrimaryKey[0]].CanonicalName
+		tname = sqlTableName(namespace, ttable)
+	}
+	scol, tcol := stable+"_"+spk, ttable+"_"+tpk
+	clauses := []string{
+		d.Quote(scol) + "\tTEXT",
+		d.Quote(tcol) + "\tTEXT"}
+	if sources {
+		clauses = append(clauses, d.Quote(ttable+"_dataset")+"\tTEXT")
 	}
-	res = append(res, fmt.Sprintf(
-		"CREATE TABLE IF NOT EXISTS `%v_%v` (", stable, ttable))
-	res = append(res, fmt.Sprintf(
-		"\t`%v_%v`\tTEXT,", stable, spk))
-	res = append(res, fmt.Sprintf(
-		"\t`%v_%v`\tTEXT,", ttable, tpk))
-	res = append(res, fmt.Sprintf(
-		"\t`%v`\tTEXT,", "context"))
-	res = append(res, fmt.Sprintf(
-		"\tFOREIGN KEY (`%v_%v`) REFERENCES `%v`(`%v`) ON DELETE CASCADE,",
-		stable, spk, stable, spk))
-	res = append(res, fmt.Sprintf(
-		"\tFOREIGN KEY (`%v_%v`) REFERENCES `%v`(`%v`) ON DELETE CASCADE",
-		ttable, tpk, ttable, tpk))
-	res = append(res, ");")
-	return strings.Join(res, "\n")
+	clauses = append(clauses,
+		d.Quote("context")+"\tTEXT",
+		sqlForeignKey(d, []string{scol}, sqlTableName(namespace, stable), []string{spk}))
+	if sources {
+		clauses = append(clauses, sqlForeignKey(d, []string{ttable + "_dataset", tcol}, tname, []string{"dataset", tpk}))
+	} else {
+		clauses = append(clauses, sqlForeignKey(d, []string{tcol}, tname, []string{tpk}))
+	}
+	return sqlCreateTable(d, sqlTableName(namespace, stable+"_"+ttable), clauses)
 }
 
 func (tbl *Table) associationTableRowsToSql(
 	fk *ForeignKey,
 	UrlToTable map[string]*Table,
 ) (rows [][]any, tableName string, colNames []string, err error) {
+	tableName, colNames, convert := tbl.associationRowConverter(fk, UrlToTable, "")
+	for _, row := range tbl.Data {
+		assocRows, err := convert(row)
+		if err != nil {
+			return rows, tableName, colNames, err
+		}
+		rows = append(rows, assocRows...)
+	}
+	return rows, tableName, colNames, nil
+}
+
+// associationRowConverter returns name and column names of the association table for fk
+// together with a function converting a row of tbl into the corresponding association table rows.
+// If namespace is not empty, rows of the association table for sources are tagged with it.
+func (tbl *Table) associationRowConverter(
+	fk *ForeignKey,
+	UrlToTable map[string]*Table,
+	namespace string,
+) (tableName string, colNames []string, convert func(map[string]any) ([][]any, error)) {
 	var (
 		ttable  string
 		tpk     string
@@ -271,36 +445,53 @@ func (tbl *Table) associationTableRowsToSql(
 		fmt.Sprintf("%v_%v", stable, spk),
 		fmt.Sprintf("%v_%v", ttable, tpk),
 		"context"}
+	sources := ttable == "SourceTable" && namespace != ""
+	if sources {
+		colNames = append(colNames, fmt.Sprintf("%v_%v", ttable, "dataset"))
+	}
 
-	for _, row := range tbl.Data {
-		vals, ok := row[colName].([]string)
-		if ok {
-			if len(vals) > 0 {
-				for _, val := range vals {
-					var (
-						context, pages string
-						found          bool
-					)
-					if ttable == "SourceTable" {
-						val, pages, found = strings.Cut(val, "[")
-						if found {
-							if strings.HasSuffix(pages, "]") {
-								context = pages[:len(pages)-1]
-							} else {
-								return rows, stable + "_" + ttable, colNames, errors.New("ill-formatted source")
-							}
-						}
-						context = ""
-					} else {
-						context = colName
-					}
-					row := []any{row[spk], val, context}
-					rows = append(rows, row)
+	convert = func(row map[string]any) (rows [][]any, err error) {
+		switch vals := row[colName].(type) {
+		case []SourceReference:
+			for _, ref := range vals {
+				if sources {
+					rows = append(rows, []any{row[spk], ref.Key, ref.Context, namespace})
+				} else {
+					rows = append(rows, []any{row[spk], ref.Key, ref.Context})
 				}
 			}
+		case []string:
+			for _, val := range vals {
+				rows = append(rows, []any{row[spk], val, colName})
+			}
+		}
+		return rows, nil
+	}
+	return sqlTableName(namespace, stable+"_"+ttable), colNames, convert
+}
+
+// sqlTableName returns the name of a table in a SQLite database, prefixed with the namespace of
+// the dataset, if any.
+func sqlTableName(namespace string, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "_" + name
+}
+
+// listItems returns the items of the value of a list-valued column formatted as strings.
+func listItems(val any) []string {
+	switch vals := val.(type) {
+	case []SourceReference:
+		res := make([]string, len(vals))
+		for i, ref := range vals {
+			res[i] = ref.String()
 		}
+		return res
+	case []string:
+		return vals
 	}
-	return rows, stable + "_" + ttable, colNames, nil
+	return nil
 }
 
 func (tbl *Table) ManyToMany() []*ForeignKey {
@@ -313,10 +504,40 @@ func (tbl *Table) ManyToMany() []*ForeignKey {
 	return manyToMany
 }
 
-func (tbl *Table) sqlCreate(UrlToTable map[string]*Table, noChecks bool) (string, error) {
+// urlColumn is an additional column of a table in a SQL database holding URLs expanded from the
+// URI templates of a column.
+type urlColumn struct {
+	name   string
+	expand func(vars map[string]any) string
+}
+
+// urlColumns returns the additional columns for the URLs of the rows - expanded from the
+// aboutUrl of the first primary key column - and for the URLs of the values of columns with
+// valueUrl.
+func (tbl *Table) urlColumns() []urlColumn {
+	var res []urlColumn
+	about := tbl.Columns[0]
+	if len(tbl.pkColumns) > 0 {
+		about = tbl.pkColumns[0]
+	}
+	if about.AboutUrl != nil {
+		res = append(res, urlColumn{"_url", about.ExpandAboutUrl})
+	}
+	for _, col := range tbl.Columns {
+		if col.ValueUrl != nil && !slices.ContainsFunc(tbl.ManyToMany(), func(fk *ForeignKey) bool {
+			return fk.ColumnReference[0] == col.Name
+		}) {
+			res = append(res, urlColumn{col.CanonicalName + "_url", col.ExpandValueUrl})
+		}
+	}
+	return res
+}
+
+// sqlCreate returns the CREATE TABLE statement for the table. If withUrls is true, columns for
+// URLs expanded from URI templates are added - see urlColumns. If namespace is not empty, the names of
+// the table and the tables it references are prefixed with it.
+func (tbl *Table) sqlCreate(UrlToTable map[string]*Table, noChecks bool, withUrls bool, namespace string, d SqlDialect) (string, error) {
 	var (
-		res        []string
-		pk         []string
 		manyToMany []string
 		clauses    []string
 	)
@@ -324,61 +545,47 @@ func (tbl *Table) sqlCreate(UrlToTable map[string]*Table, noChecks bool) (string
 		manyToMany = append(manyToMany, fk.ColumnReference[0])
 	}
 	nameToCol := tbl.nameToCol()
-	if len(tbl.PrimaryKey) > 0 {
-		pk = append(pk, "PRIMARY KEY(")
-		for i, col := range tbl.PrimaryKey {
-			if i > 0 {
-				pk = append(pk, ",")
+	for _, col := range tbl.Columns {
+		if !slices.Contains(manyToMany, col.Name) {
+			clause, err := col.sqlCreate(noChecks, d)
+			if err != nil {
+				return "", err
 			}
-			pk = append(pk, fmt.Sprintf("`%v`", nameToCol[col].CanonicalName))
+			clauses = append(clauses, clause)
 		}
-		pk = append(pk, ")")
 	}
-	for i, col := range tbl.Columns {
-		if !slices.Contains(manyToMany, col.Name) {
-			clause := ""
-			if i == 0 {
-				clause += "\t"
-			}
-			clauses = append(clauses, clause+col.sqlCreate(noChecks))
+	if withUrls {
+		for _, col := range tbl.urlColumns() {
+			clauses = append(clauses, d.Quote(col.name)+"\tTEXT")
 		}
 	}
-	if len(pk) > 0 {
-		clauses = append(clauses, strings.Join(pk, ""))
+	if len(tbl.PrimaryKey) > 0 {
+		pk := make([]string, len(tbl.PrimaryKey))
+		for i, col := range tbl.PrimaryKey {
+			pk[i] = nameToCol[col].CanonicalName
+		}
+		clauses = append(clauses, fmt.Sprintf("PRIMARY KEY(%v)", sqlQuoteAll(d, pk...)))
 	}
 
 	for _, fk := range tbl.ForeignKeys {
 		if !fk.ManyToMany {
-			clause := "FOREIGN KEY("
+			cols := make([]string, len(fk.ColumnReference))
 			for i, col := range fk.ColumnReference {
-				if i > 0 {
-					clause += ","
-				}
-				clause += fmt.Sprintf("`%v`", nameToCol[col].CanonicalName)
+				cols[i] = nameToCol[col].CanonicalName
 			}
-			clause += ") REFERENCES "
 			ttable := UrlToTable[fk.Reference.Resource]
-			clause += fmt.Sprintf("`%v`(", ttable.CanonicalName)
+			tcols := make([]string, len(fk.Reference.ColumnReference))
 			for i, col := range fk.Reference.ColumnReference {
-				if i > 0 {
-					clause += ","
-				}
 				val, ok := ttable.nameToCol()[col]
-				if ok {
-					clause += fmt.Sprintf("`%v`", val.CanonicalName)
-				} else {
+				if !ok {
 					return "", errors.New(fmt.Sprintf("unknown column: %v '%v' %v", tbl.Url, col, nameToCol))
 				}
+				tcols[i] = val.CanonicalName
 			}
-			clause += ") ON DELETE CASCADE"
-			clauses = append(clauses, clause)
+			clauses = append(clauses, sqlForeignKey(d, cols, sqlTableName(namespace, ttable.CanonicalName), tcols))
 		}
 	}
-
-	res = append(res, fmt.Sprintf("CREATE TABLE IF NOT EXISTS `%v` (", tbl.CanonicalName))
-	res = append(res, strings.Join(clauses, ",\n\t"))
-	res = append(res, ");")
-	return strings.Join(res, "\n"), nil
+	return sqlCreateTable(d, sqlTableName(namespace, tbl.CanonicalName), clauses), nil
 }
 
 // rowsToSql returns
@@ -386,6 +593,22 @@ func (tbl *Table) sqlCreate(UrlToTable map[string]*Table, noChecks bool) (string
 //     for insertion into SQLite.
 //   - a slice of column names representing the column names (in order) for the rows.
 func (tbl *Table) rowsToSql() (rows [][]any, colNames []string, err error) {
+	colNames, convert := tbl.rowConverter(false, SQLite)
+	rows = make([][]any, len(tbl.Data))
+	for i, row := range tbl.Data {
+		rows[i], err = convert(row)
+		if err != nil {
+			return rows, colNames, err
+		}
+	}
+	return rows, colNames, nil
+}
+
+// rowConverter returns the column names (in order) of the table in a SQLite database together
+// with a function converting a row of the table into a slice of values formatted for insertion.
+// If withUrls is true, the URLs for the columns returned by urlColumns are appended. Values are
+// converted for the SQL dialect d.
+func (tbl *Table) rowConverter(withUrls bool, d SqlDialect) (colNames []string, convert func(map[string]any) ([]any, error)) {
 	var manyToMany []string
 	for _, fk := range tbl.ManyToMany() {
 		manyToMany = append(manyToMany, fk.ColumnReference[0])
@@ -402,23 +625,38 @@ func (tbl *Table) rowsToSql() (rows [][]any, colNames []string, err error) {
 		}
 		// ManyToMany columns are skipped, because these values are turned into rows in association tables.
 	}
-	// Now we assemble the rows:
-	rows = make([][]any, len(tbl.Data))
-	for i, row := range tbl.Data {
-		rows[i] = make([]any, len(colNames))
-		for j, col := range colNames {
+	var urlCols []urlColumn
+	if withUrls {
+		urlCols = tbl.urlColumns()
+	}
+	n := len(colNames)
+	for _, col := range urlCols {
+		colNames = append(colNames, col.name)
+	}
+	convert = func(row map[string]any) ([]any, error) {
+		res := make([]any, len(colNames))
+		if len(urlCols) > 0 {
+			vars := tbl.TemplateVariables(row)
+			for j, col := range urlCols {
+				if u := col.expand(vars); u != "" {
+					res[n+j] = u
+				}
+			}
+		}
+		for j, col := range colNames[:n] {
 			sep, ok := listValued[col]
 			if ok {
 				// List-valued columns are assumed to be of datatype string.
-				rows[i][j] = strings.Join(row[col].([]string), sep)
+				res[j] = strings.Join(listItems(row[col]), sep)
 			} else {
-				val, err := colMap[col].ToSql(row[col])
+				val, err := d.ToSql(&colMap[col].Datatype, row[col])
 				if err != nil {
-					return rows, colNames, err
+					return res, err
 				}
-				rows[i][j] = val
+				res[j] = val
 			}
 		}
+		return res, nil
 	}
-	return rows, colNames, nil
+	return colNames, convert
 }
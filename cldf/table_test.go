@@ -1,8 +1,11 @@
 package cldf
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"os"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -118,7 +121,7 @@ func TestTable_fk(t *testing.T) {
 	}
 	tbl2 := makeTable("table_simple.json", true)
 	urlToTable := map[string]*Table{"table_simple.csv": &tbl2}
-	sql, _ := tbl.sqlCreate(urlToTable, false)
+	sql, _ := tbl.sqlCreate(urlToTable, false, false, "", SQLite)
 	if !strings.Contains(sql, "PRIMARY KEY") {
 		t.Errorf(`problem`)
 	}
@@ -126,7 +129,7 @@ func TestTable_fk(t *testing.T) {
 	if len(data) != 3 {
 		t.Errorf(`problem: %v vs %v`, len(data), 3)
 	}
-	sql = tbl.sqlCreateAssociationTable(*tbl.ManyToMany()[0], urlToTable)
+	sql = tbl.sqlCreateAssociationTable(*tbl.ManyToMany()[0], urlToTable, "", SQLite)
 	if !strings.Contains(sql, "context") {
 		t.Errorf(`problem`)
 	}
@@ -141,3 +144,77 @@ func TestTable_fk(t *testing.T) {
 		t.Errorf(`problem`)
 	}
 }
+
+func TestTable_Rows(t *testing.T) {
+	tbl := makeTable("table_simple.json", false)
+	var numbers []int
+	for row, err := range tbl.Rows(context.Background(), "testdata/", makeDialect(), false) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		numbers = append(numbers, row.Number)
+	}
+	if !slices.Equal(numbers, []int{1, 2, 3}) {
+		t.Errorf(`problem: %v`, numbers)
+	}
+	if len(tbl.Data) != 0 {
+		t.Errorf(`problem: rows must not be stored in Data`)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, err := range tbl.Rows(ctx, "testdata/", makeDialect(), false) {
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf(`problem: expected context.Canceled, got %v`, err)
+		}
+	}
+}
+
+func TestTable_with_preamble(t *testing.T) {
+	tbl := makeTable("table_with_preamble.json", true)
+	if len(tbl.Data) != 2 {
+		t.Fatalf(`problem: expected %v rows got %v`, 2, len(tbl.Data))
+	}
+	if tbl.Data[0]["ID"] != "a" || tbl.Data[1]["Col"] != "v" {
+		t.Errorf(`problem: %v`, tbl.Data)
+	}
+}
+
+func TestTable_with_blank_rows(t *testing.T) {
+	tbl := makeTable("table_with_blank_rows.json", false)
+	var numbers []int
+	for row, err := range tbl.Rows(context.Background(), "testdata/", makeDialect(), false) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		numbers = append(numbers, row.Number)
+		tbl.Data = append(tbl.Data, row.Data)
+	}
+	if !slices.Equal(numbers, []int{1, 2, 3}) {
+		t.Errorf(`problem: %v`, numbers)
+	}
+	if tbl.Data[1]["ID"] != nil || tbl.Data[1]["Col"] != nil || tbl.Data[2]["ID"] != "b" {
+		t.Errorf(`problem: %v`, tbl.Data)
+	}
+}
+
+func TestTable_inheritedPropertyUrl(t *testing.T) {
+	tbl, err := NewTable(map[string]any{
+		"url": "languages.csv",
+		"tableSchema": map[string]any{
+			"propertyUrl": "http://cldf.clld.org/v1.0/terms.rdf#name",
+			"columns": []any{
+				map[string]any{"name": "ID", "propertyUrl": "http://cldf.clld.org/v1.0/terms.rdf#id"},
+				map[string]any{"name": "Name"},
+			},
+		},
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tbl.Columns[0].CanonicalName != "cldf_id" || tbl.Columns[1].CanonicalName != "cldf_name" {
+		t.Errorf(`problem: %v %v`, tbl.Columns[0].CanonicalName, tbl.Columns[1].CanonicalName)
+	}
+	if tbl.Columns[1].PropertyUrl.String() != "http://cldf.clld.org/v1.0/terms.rdf#name" {
+		t.Errorf(`problem: %v`, tbl.Columns[1].PropertyUrl)
+	}
+}
@@ -0,0 +1,201 @@
+package cldf
+
+import (
+	"fmt"
+	"gocldf/cldf/ontology"
+	"net/url"
+	"reflect"
+)
+
+// UnmarshalTypeError reports a value of a column which cannot be stored in the struct field
+// the column is mapped to.
+type UnmarshalTypeError struct {
+	Table  string
+	Row    int
+	Column string
+	Field  string
+	Value  any
+	Type   reflect.Type // The type of the struct field
+}
+
+func (e *UnmarshalTypeError) Error() string {
+	return fmt.Sprintf(
+		"%v row %v column %v: cannot unmarshal value of type %T into field %v of type %v",
+		e.Table, e.Row, e.Column, e.Value, e.Field, e.Type)
+}
+
+// fieldColumn maps a struct field - specified by its index sequence - to a column.
+type fieldColumn struct {
+	index []int
+	field string
+	col   *Column
+}
+
+/*
+Unmarshal stores the rows of the table in v, which must be a pointer to a slice of structs or of
+pointers to structs.
+
+Struct fields are mapped to columns via "cldf" tags, specifying either a CLDF property or a
+column name:
+
+	type MyValue struct {
+		ID       string    `cldf:"id"`
+		Language string    `cldf:"languageReference"`
+		Count    *int      `cldf:"Count"`
+		Sources  []string  `cldf:"source"`
+	}
+
+Fields without tag - or tagged with "-" - are ignored. Values are assigned as returned by
+Column.ToGo: A field must be of the value's type (e.g. time.Time, *url.URL, []byte, []string for
+list-valued columns), a pointer to it, or - for numbers - a convertible numeric type. Null values
+leave fields at their zero value. Values which cannot be assigned are reported as
+*UnmarshalTypeError.
+*/
+func (tbl *Table) Unmarshal(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("cannot unmarshal into %T: pointer to slice required", v)
+	}
+	slice := rv.Elem()
+	elemType := slice.Type().Elem()
+	structType := elemType
+	if elemType.Kind() == reflect.Pointer {
+		structType = elemType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return fmt.Errorf("cannot unmarshal into %T: slice of structs required", v)
+	}
+	fields, err := tbl.fieldColumns(structType)
+	if err != nil {
+		return err
+	}
+	res := reflect.MakeSlice(slice.Type(), len(tbl.Data), len(tbl.Data))
+	for i, row := range tbl.Data {
+		elem := res.Index(i)
+		if elemType.Kind() == reflect.Pointer {
+			elem.Set(reflect.New(structType))
+			elem = elem.Elem()
+		}
+		if err = tbl.unmarshalRow(row, tbl.rowNumber(i), elem, fields); err != nil {
+			return err
+		}
+	}
+	slice.Set(res)
+	return nil
+}
+
+// UnmarshalRow stores a row of the table in the struct pointed to by v. See Unmarshal.
+func (tbl *Table) UnmarshalRow(row map[string]any, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("cannot unmarshal into %T: pointer to struct required", v)
+	}
+	fields, err := tbl.fieldColumns(rv.Elem().Type())
+	if err != nil {
+		return err
+	}
+	return tbl.unmarshalRow(row, 0, rv.Elem(), fields)
+}
+
+// Rows returns the rows of a table unmarshalled into structs of type T. See Table.Unmarshal.
+func Rows[T any](tbl *Table) ([]T, error) {
+	var res []T
+	err := tbl.Unmarshal(&res)
+	return res, err
+}
+
+// fieldColumns maps the tagged fields of a struct type to columns of the table.
+func (tbl *Table) fieldColumns(t reflect.Type) ([]fieldColumn, error) {
+	var res []fieldColumn
+	nameToCol := tbl.nameToCol()
+	for _, field := range reflect.VisibleFields(t) {
+		tag, ok := field.Tag.Lookup("cldf")
+		if !ok || tag == "-" || !field.IsExported() {
+			continue
+		}
+		col, ok := nameToCol[tag]
+		if ontology.IsProperty(tag) {
+			for _, c := range tbl.Columns {
+				if c.CanonicalName == "cldf_"+tag {
+					col, ok = c, true
+					break
+				}
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("field %v: no column %v in table %v", field.Name, tag, tbl.Url)
+		}
+		res = append(res, fieldColumn{field.Index, field.Name, col})
+	}
+	return res, nil
+}
+
+func (tbl *Table) unmarshalRow(row map[string]any, number int, v reflect.Value, fields []fieldColumn) error {
+	for _, fc := range fields {
+		val := row[fc.col.CanonicalName]
+		field, err := v.FieldByIndexErr(fc.index)
+		if err != nil {
+			return fmt.Errorf("field %v: %w", fc.field, err)
+		}
+		if !assign(field, val) {
+			return &UnmarshalTypeError{
+				Table:  tbl.Url,
+				Row:    number,
+				Column: fc.col.Name,
+				Field:  fc.field,
+				Value:  val,
+				Type:   field.Type()}
+		}
+	}
+	return nil
+}
+
+// assign stores val in field, returning false if the types are incompatible.
+func assign(field reflect.Value, val any) bool {
+	if val == nil {
+		field.SetZero()
+		return true
+	}
+	rv := reflect.ValueOf(val)
+	switch {
+	case rv.Type().AssignableTo(field.Type()):
+		field.Set(rv)
+		return true
+	case isNumber(rv.Kind()) && isNumber(field.Kind()):
+		converted := rv.Convert(field.Type())
+		if !converted.Convert(rv.Type()).Equal(rv) || (rv.CanInt() && rv.Int() < 0 && converted.CanUint()) {
+			return false // The value does not fit into the field's type.
+		}
+		field.Set(converted)
+		return true
+	case field.Kind() == reflect.Pointer:
+		ptr := reflect.New(field.Type().Elem())
+		if !assign(ptr.Elem(), val) {
+			return false
+		}
+		field.Set(ptr)
+		return true
+	}
+	switch val := val.(type) {
+	case *url.URL:
+		// URLs may also be stored as url.URL values.
+		return assign(field, *val)
+	case []SourceReference:
+		// Source references may also be stored as formatted strings.
+		if field.Type() == reflect.TypeOf([]string{}) {
+			field.Set(reflect.ValueOf(listItems(val)))
+			return true
+		}
+	}
+	return false
+}
+
+func isNumber(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package cldf
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func makeTypedTable(t *testing.T) *Table {
+	tbl, err := NewTable(map[string]any{
+		"url": "typed.csv",
+		"tableSchema": map[string]any{
+			"columns": []any{
+				map[string]any{"name": "ID", "propertyUrl": "http://cldf.clld.org/v1.0/terms.rdf#id"},
+				map[string]any{"name": "Count", "datatype": "integer"},
+				map[string]any{"name": "Ratio", "datatype": "decimal"},
+				map[string]any{"name": "Date", "datatype": "date"},
+				map[string]any{"name": "Url", "datatype": "anyURI"},
+				map[string]any{"name": "Data", "datatype": "base64Binary"},
+				map[string]any{"name": "Flag", "datatype": "boolean"},
+				map[string]any{"name": "Tags", "separator": " "},
+				map[string]any{"name": "Source", "separator": ";", "propertyUrl": "http://cldf.clld.org/v1.0/terms.rdf#source"},
+			},
+		},
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, fields := range [][]string{
+		{"r1", "3", "0.5", "2024-01-31", "https://example.org", "aGVsbG8=", "true", "a b", "src[1]"},
+		{"r2", "", "", "", "", "", "", "", ""},
+	} {
+		row, errs := tbl.readRow(fields, i+1, false)
+		if len(errs) > 0 {
+			t.Fatal(errs[0])
+		}
+		tbl.Data = append(tbl.Data, row)
+	}
+	return tbl
+}
+
+func TestTable_Unmarshal(t *testing.T) {
+	type row struct {
+		ID      string    `cldf:"id"`
+		Count   *int64    `cldf:"Count"`
+		Ratio   float64   `cldf:"Ratio"`
+		Date    time.Time `cldf:"Date"`
+		Url     *url.URL  `cldf:"Url"`
+		Data    []byte    `cldf:"Data"`
+		Flag    bool      `cldf:"Flag"`
+		Tags    []string  `cldf:"Tags"`
+		Sources []string  `cldf:"source"`
+		Ignored string
+	}
+	tbl := makeTypedTable(t)
+	rows, err := Rows[row](tbl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := rows[0]
+	if r.ID != "r1" || *r.Count != 3 || r.Ratio != 0.5 || r.Date.Month() != time.January || r.Url.Host != "example.org" {
+		t.Errorf(`problem: %v`, r)
+	}
+	if string(r.Data) != "hello" || !r.Flag || len(r.Tags) != 2 || r.Sources[0] != "src[1]" {
+		t.Errorf(`problem: %v`, r)
+	}
+	if rows[1].Count != nil || rows[1].Url != nil || len(rows[1].Tags) != 0 {
+		t.Errorf(`problem: %v`, rows[1])
+	}
+
+	var ptrs []*row
+	if err = tbl.Unmarshal(&ptrs); err != nil || len(ptrs) != 2 || ptrs[1].ID != "r2" {
+		t.Errorf(`problem: %v %v`, ptrs, err)
+	}
+	var single struct {
+		Refs []SourceReference `cldf:"Source"`
+	}
+	if err = tbl.UnmarshalRow(tbl.Data[0], &single); err != nil || single.Refs[0].Context != "1" {
+		t.Errorf(`problem: %v %v`, single, err)
+	}
+}
+
+func TestTable_UnmarshalError(t *testing.T) {
+	tbl := makeTypedTable(t)
+	_, err := Rows[struct {
+		Count string `cldf:"Count"`
+	}](tbl)
+	var typeErr *UnmarshalTypeError
+	if !errors.As(err, &typeErr) || typeErr.Row != 1 || typeErr.Column != "Count" {
+		t.Errorf(`problem: %v`, err)
+	}
+	_, err = Rows[struct {
+		Count int8 `cldf:"Ratio"`
+	}](tbl)
+	if !errors.As(err, &typeErr) {
+		t.Errorf(`problem: %v`, err)
+	}
+	_, err = Rows[struct {
+		X string `cldf:"Unknown"`
+	}](tbl)
+	if err == nil {
+		t.Errorf(`problem: unknown columns must be reported`)
+	}
+	if err = tbl.Unmarshal([]struct{}{}); err == nil {
+		t.Errorf(`problem: non-pointer must be rejected`)
+	}
+}
@@ -0,0 +1,216 @@
+package cldf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// URITemplate is a URI template as specified in RFC 6570, e.g. the aboutUrl, propertyUrl or
+// valueUrl of a column. All four levels of the specification are supported except for
+// associative array values.
+type URITemplate struct {
+	raw   string
+	parts []templatePart
+}
+
+// templatePart is either a literal part of a template or an expression.
+type templatePart struct {
+	literal  string
+	operator byte // 0 for simple string expansion
+	varSpecs []varSpec
+}
+
+type varSpec struct {
+	name    string
+	prefix  int // Maximal number of characters of the value to use, 0 for no limit
+	explode bool
+}
+
+// operatorSpecs maps expression operators to the string to start an expansion with, the
+// separator of values, whether values are named, the string to use for empty named values and
+// whether reserved characters are allowed in values - see RFC 6570, Appendix A.
+var operatorSpecs = map[byte]struct {
+	first, sep    string
+	named         bool
+	ifEmpty       string
+	allowReserved bool
+}{
+	0:   {"", ",", false, "", false},
+	'+': {"", ",", false, "", true},
+	'.': {".", ".", false, "", false},
+	'/': {"/", "/", false, "", false},
+	';': {";", ";", true, "", false},
+	'?': {"?", "&", true, "=", false},
+	'&': {"&", "&", true, "=", false},
+	'#': {"#", ",", false, "", true},
+}
+
+// ParseURITemplate parses a URI template.
+func ParseURITemplate(s string) (*URITemplate, error) {
+	res := &URITemplate{raw: s}
+	rest := s
+	for rest != "" {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			if strings.IndexByte(rest, '}') >= 0 {
+				return nil, fmt.Errorf("invalid URI template %q: unmatched }", s)
+			}
+			res.parts = append(res.parts, templatePart{literal: rest})
+			break
+		}
+		if start > 0 {
+			if strings.IndexByte(rest[:start], '}') >= 0 {
+				return nil, fmt.Errorf("invalid URI template %q: unmatched }", s)
+			}
+			res.parts = append(res.parts, templatePart{literal: rest[:start]})
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("invalid URI template %q: unclosed expression", s)
+		}
+		part, err := parseExpression(rest[start+1 : start+end])
+		if err != nil {
+			return nil, fmt.Errorf("invalid URI template %q: %w", s, err)
+		}
+		res.parts = append(res.parts, part)
+		rest = rest[start+end+1:]
+	}
+	return res, nil
+}
+
+func parseExpression(expr string) (templatePart, error) {
+	var res templatePart
+	if expr != "" {
+		if _, ok := operatorSpecs[expr[0]]; ok {
+			res.operator = expr[0]
+			expr = expr[1:]
+		} else if strings.IndexByte("=,!@|", expr[0]) >= 0 {
+			return res, fmt.Errorf("reserved operator %c", expr[0])
+		}
+	}
+	for _, spec := range strings.Split(expr, ",") {
+		var vs varSpec
+		if name, ok := strings.CutSuffix(spec, "*"); ok {
+			vs.explode, spec = true, name
+		}
+		if name, length, ok := strings.Cut(spec, ":"); ok {
+			n, err := strconv.Atoi(length)
+			if err != nil || n <= 0 || n >= 10000 {
+				return res, fmt.Errorf("invalid prefix modifier %q", length)
+			}
+			vs.prefix, spec = n, name
+		}
+		if spec == "" || strings.ContainsFunc(spec, func(r rune) bool {
+			return !(r == '_' || r == '.' || r == '%' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+		}) {
+			return res, fmt.Errorf("invalid variable name %q", spec)
+		}
+		vs.name = spec
+		res.varSpecs = append(res.varSpecs, vs)
+	}
+	return res, nil
+}
+
+// String returns the template as parsed.
+func (t *URITemplate) String() string {
+	if t == nil {
+		return ""
+	}
+	return t.raw
+}
+
+// Expand expands the template with the given variables, whose values must be strings or slices
+// of strings. Undefined variables and null values - nil or empty slices - are ignored. Expanding
+// a nil template yields the empty string.
+func (t *URITemplate) Expand(vars map[string]any) string {
+	if t == nil {
+		return ""
+	}
+	var b strings.Builder
+	for _, part := range t.parts {
+		if part.varSpecs == nil {
+			b.WriteString(part.literal)
+			continue
+		}
+		op := operatorSpecs[part.operator]
+		first := true
+		for _, vs := range part.varSpecs {
+			var values []string
+			switch v := vars[vs.name].(type) {
+			case string:
+				values = []string{v}
+			case []string:
+				values = v
+			}
+			if len(values) == 0 {
+				continue
+			}
+			if first {
+				b.WriteString(op.first)
+				first = false
+			} else {
+				b.WriteString(op.sep)
+			}
+			_, isList := vars[vs.name].([]string)
+			switch {
+			case !isList:
+				value := values[0]
+				if vs.prefix > 0 && len([]rune(value)) > vs.prefix {
+					value = string([]rune(value)[:vs.prefix])
+				}
+				writeNamed(&b, op.named, op.ifEmpty, vs.name, encode(value, op.allowReserved))
+			case !vs.explode:
+				encoded := make([]string, len(values))
+				for i, value := range values {
+					encoded[i] = encode(value, op.allowReserved)
+				}
+				writeNamed(&b, op.named, op.ifEmpty, vs.name, strings.Join(encoded, ","))
+			default:
+				for i, value := range values {
+					if i > 0 {
+						b.WriteString(op.sep)
+					}
+					writeNamed(&b, op.named, op.ifEmpty, vs.name, encode(value, op.allowReserved))
+				}
+			}
+		}
+	}
+	return b.String()
+}
+
+func writeNamed(b *strings.Builder, named bool, ifEmpty string, name string, value string) {
+	if named {
+		b.WriteString(name)
+		if value == "" {
+			b.WriteString(ifEmpty)
+			return
+		}
+		b.WriteString("=")
+	}
+	b.WriteString(value)
+}
+
+// encode percent-encodes all characters of s but the unreserved ones - and the reserved ones
+// and percent-encoded triplets if allowReserved is true.
+func encode(s string, allowReserved bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte("-._~", c) >= 0:
+			b.WriteByte(c)
+		case allowReserved && strings.IndexByte(":/?#[]@!$&'()*+,;=", c) >= 0:
+			b.WriteByte(c)
+		case allowReserved && c == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]):
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func isHex(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
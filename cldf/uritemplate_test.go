@@ -0,0 +1,71 @@
+package cldf
+
+import (
+	"testing"
+)
+
+func TestURITemplate_Expand(t *testing.T) {
+	// Examples from RFC 6570, section 3.2
+	vars := map[string]any{
+		"var":   "value",
+		"hello": "Hello World!",
+		"path":  "/foo/bar",
+		"empty": "",
+		"list":  []string{"red", "green", "blue"},
+		"x":     "1024",
+		"y":     "768",
+	}
+	for tmpl, expected := range map[string]string{
+		"{var}":              "value",
+		"{hello}":            "Hello%20World%21",
+		"{+path}/here":       "/foo/bar/here",
+		"here?ref={+path}":   "here?ref=/foo/bar",
+		"{#hello}":           "#Hello%20World!",
+		"{x,y}":              "1024,768",
+		"{var:3}":            "val",
+		"{list}":             "red,green,blue",
+		"{list*}":            "red,green,blue",
+		"X{.list*}":          "X.red.green.blue",
+		"{/var,x}/here":      "/value/1024/here",
+		"{/list*}":           "/red/green/blue",
+		"{;x,y,empty}":       ";x=1024;y=768;empty",
+		"{?x,y,empty}":       "?x=1024&y=768&empty=",
+		"{?list*}":           "?list=red&list=green&list=blue",
+		"?fixed=yes{&x}":     "?fixed=yes&x=1024",
+		"{undef}{?undef}":    "",
+		"map?{x,y,undef}":    "map?1024,768",
+		"languages.csv{#ID}": "languages.csv",
+	} {
+		parsed, err := ParseURITemplate(tmpl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := parsed.Expand(vars); actual != expected {
+			t.Errorf(`problem: %v: %v vs %v`, tmpl, actual, expected)
+		}
+	}
+}
+
+func TestParseURITemplate_invalid(t *testing.T) {
+	for _, tmpl := range []string{"{var", "var}", "{}", "{=var}", "{var:x}", "{v a r}"} {
+		if _, err := ParseURITemplate(tmpl); err == nil {
+			t.Errorf(`problem: %v accepted`, tmpl)
+		}
+	}
+}
+
+func TestColumn_ExpandValueUrl(t *testing.T) {
+	ds := makeDataset("StructureDataset-metadata.json")
+	tbl := ds.Tables["LanguageTable"]
+	vars := tbl.TemplateVariables(map[string]any{"cldf_glottocode": "khar1287"})
+	for _, col := range tbl.Columns {
+		if col.CanonicalName == "cldf_glottocode" {
+			expected := "http://glottolog.org/resource/languoid/id/khar1287"
+			if actual := col.ExpandValueUrl(vars); actual != expected {
+				t.Errorf(`problem: %v vs %v`, actual, expected)
+			}
+		} else if actual := col.ExpandValueUrl(vars); actual != "" {
+			t.Errorf(`problem: %v`, actual)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package cldf
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"gocldf/cldf/datatype"
+	"gocldf/cldf/ontology"
+	"slices"
+)
+
+// ValidationError describes invalid data in a table of a dataset.
+type ValidationError struct {
+	Table  string // The URL of the table
+	Row    int    // The number of the row, 0 if the error does not concern a particular row
+	Column string // The name of the column, empty if the error does not concern a particular cell
+	Value  string // The raw value of the cell
+	Rule   string // The rule violated, e.g. "required", "datatype" or the name of a datatype constraint
+	Err    error
+	// Warning marks findings which do not make the dataset invalid, e.g. terms unknown to the
+	// embedded ontology, which may have been added in a later version of CLDF.
+	Warning bool
+}
+
+func newCellError(tbl *Table, row int, col *Column, value string, err error) *ValidationError {
+	var (
+		rule            = "datatype"
+		constraintError *datatype.ConstraintError
+	)
+	if errors.Is(err, errRequired) {
+		rule = "required"
+	} else if errors.As(err, &constraintError) {
+		rule = constraintError.Constraint
+	}
+	return &ValidationError{Table: tbl.Url, Row: row, Column: col.Name, Value: value, Rule: rule, Err: err}
+}
+
+func (e *ValidationError) Error() string {
+	loc := e.Table
+	if loc == "" {
+		loc = "dataset"
+	}
+	if e.Row > 0 {
+		loc += fmt.Sprintf(" row %v", e.Row)
+	}
+	if e.Warning {
+		loc = "warning: " + loc
+	}
+	if e.Column != "" {
+		return fmt.Sprintf("%v column %v: %v %q [%v]", loc, e.Column, e.Err, e.Value, e.Rule)
+	}
+	return fmt.Sprintf("%v: %v [%v]", loc, e.Err, e.Rule)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// sortValidationErrors sorts errors by table and row, keeping the order of errors within rows.
+func sortValidationErrors(errs []*ValidationError) {
+	slices.SortStableFunc(errs, func(a, b *ValidationError) int {
+		return cmp.Or(cmp.Compare(a.Table, b.Table), cmp.Compare(a.Row, b.Row))
+	})
+}
+
+// Validate loads the data of the dataset and checks
+//   - cell values against the column descriptions,
+//   - uniqueness of primary keys,
+//   - referential integrity of foreign keys,
+//   - that source references can be resolved in Sources,
+//   - that the dataset contains the components and columns required by its CLDF module.
+//
+// All validation errors are returned, sorted by table and row. If maxErrors is positive, at most
+// this number of errors - not counting warnings - is returned. The data is read completely in any
+// case, because foreign keys can only be checked against all rows of the referenced tables.
+// Errors preventing the validation are returned as error.
+func (dataset *Dataset) Validate(maxErrors int) ([]*ValidationError, error) {
+	errs, err := dataset.LoadDataWithErrors(0)
+	if err != nil {
+		return nil, err
+	}
+	errs = append(errs, dataset.CheckConformance()...)
+	errs = append(errs, dataset.CheckForeignKeys()...)
+	sortValidationErrors(errs)
+	if maxErrors <= 0 {
+		return errs, nil
+	}
+	var res []*ValidationError
+	n := 0
+	for _, verr := range errs {
+		if !verr.Warning {
+			if n == maxErrors {
+				continue
+			}
+			n++
+		}
+		res = append(res, verr)
+	}
+	return res, nil
+}
+
+// CheckConformance checks whether the dataset conforms to the CLDF ontology, i.e. whether
+//   - the dataset contains the components required by its module,
+//   - components contain columns for the properties they require,
+//   - all CLDF terms used as dc:conformsTo or propertyUrl are defined in the ontology.
+//
+// Since the embedded ontology may lack terms of later CLDF versions, unknown terms are reported
+// as warnings.
+func (dataset *Dataset) CheckConformance() (errs []*ValidationError) {
+	if !ontology.IsModule(dataset.Module) {
+		errs = append(errs, &ValidationError{
+			Rule:    "module",
+			Err:     fmt.Errorf("unknown CLDF module %q", dataset.Module),
+			Warning: true})
+	}
+	for _, comp := range ontology.RequiredComponents(dataset.Module) {
+		if _, ok := dataset.Tables[comp]; !ok {
+			errs = append(errs, &ValidationError{
+				Rule: "component",
+				Err:  fmt.Errorf("%v requires a %v", dataset.Module, comp)})
+		}
+	}
+	for _, tbl := range dataset.Tables {
+		if tbl.Comp != "" {
+			if comp, ok := ontology.Term(tbl.Comp); ok && !ontology.IsComponent(comp) {
+				errs = append(errs, &ValidationError{
+					Table:   tbl.Url,
+					Rule:    "component",
+					Err:     fmt.Errorf("unknown CLDF component %v", tbl.Comp),
+					Warning: true})
+			}
+		}
+		for _, col := range tbl.Columns {
+			if prop, ok := ontology.Term(col.PropertyUrl.String()); ok && !ontology.IsProperty(prop) {
+				errs = append(errs, &ValidationError{
+					Table:   tbl.Url,
+					Column:  col.Name,
+					Value:   col.PropertyUrl.String(),
+					Rule:    "property",
+					Err:     errors.New("unknown CLDF property"),
+					Warning: true})
+			}
+		}
+		for _, prop := range ontology.RequiredProperties(tbl.CanonicalName) {
+			if !slices.ContainsFunc(tbl.Columns, func(col *Column) bool { return col.CanonicalName == "cldf_"+prop }) {
+				errs = append(errs, &ValidationError{
+					Table: tbl.Url,
+					Rule:  "property",
+					Err:   fmt.Errorf("%v requires a column with propertyUrl %v", tbl.CanonicalName, prop)})
+			}
+		}
+	}
+	return errs
+}
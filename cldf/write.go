@@ -0,0 +1,134 @@
+package cldf
+
+import (
+	"encoding/json"
+	"errors"
+	"gocldf/internal/jsonutil"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Write writes the dataset to dir, i.e. the metadata as JSON file, the data of each table as
+// CSV file formatted according to the table's dialect and the sources as BibTeX file.
+//
+// Tables are written from the data loaded into memory. Keys of the metadata which are not
+// interpreted by this package are preserved, so reading a written dataset yields the same
+// metadata and data.
+func (dataset *Dataset) Write(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	metadata := make(map[string]any, len(dataset.Metadata)+1)
+	for k, v := range dataset.Metadata {
+		metadata[k] = v
+	}
+	tables := make([]any, 0, len(dataset.Tables))
+	for _, tbl := range dataset.orderedByMetadata() {
+		tables = append(tables, tbl.metadata)
+		dialect := dataset.Dialect
+		if tbl.Dialect != nil {
+			dialect = tbl.Dialect
+		}
+		if err := tbl.write(filepath.Join(dir, tbl.Url), dialect); err != nil {
+			return err
+		}
+	}
+	metadata["tables"] = tables
+	if err := writeJSON(filepath.Join(dir, filepath.Base(dataset.MetadataPath)), metadata); err != nil {
+		return err
+	}
+	if dataset.Sources != nil {
+		sourcesBibtex, err := jsonutil.GetString(dataset.Metadata, "dc:source", "sources.bib")
+		if err != nil {
+			return err
+		}
+		return writeFile(filepath.Join(dir, sourcesBibtex), dataset.Sources.Write)
+	}
+	return nil
+}
+
+// orderedByMetadata returns the tables of the dataset in the order of the metadata.
+func (dataset *Dataset) orderedByMetadata() []*Table {
+	res := make([]*Table, 0, len(dataset.Tables))
+	for _, name := range dataset.order {
+		if tbl, ok := dataset.Tables[name]; ok {
+			res = append(res, tbl)
+		}
+	}
+	return res
+}
+
+// write writes the data of the table as CSV file to p.
+func (tbl *Table) write(p string, dialect *Dialect) error {
+	return writeFile(p, func(f io.Writer) error {
+		w := NewCsvWriter(f, dialect)
+		if dialect.headerRowCount > 0 {
+			header := make([]string, len(tbl.Columns))
+			for i, col := range tbl.Columns {
+				header[i] = col.Name
+			}
+			if err := w.Write(header); err != nil {
+				return err
+			}
+			for i := 1; i < dialect.headerRowCount; i++ {
+				if err := w.Write(make([]string, len(tbl.Columns))); err != nil {
+					return err
+				}
+			}
+		}
+		for _, row := range tbl.Data {
+			record, err := tbl.formatRow(row)
+			if err != nil {
+				return err
+			}
+			if err = w.Write(record); err != nil {
+				return err
+			}
+		}
+		return w.Flush()
+	})
+}
+
+// formatRow formats the values of a row as strings, suitable to be read back by readRow.
+func (tbl *Table) formatRow(row map[string]any) ([]string, error) {
+	record := make([]string, len(tbl.Columns))
+	for i, col := range tbl.Columns {
+		val := row[col.CanonicalName]
+		if col.Separator != "" && val != nil {
+			record[i] = strings.Join(listItems(val), col.Separator)
+			continue
+		}
+		s, err := col.ToString(val)
+		if err != nil {
+			return nil, err
+		}
+		record[i] = s
+	}
+	return record, nil
+}
+
+func writeJSON(p string, obj any) error {
+	return writeFile(p, func(f io.Writer) error {
+		enc := json.NewEncoder(f)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "    ")
+		return enc.Encode(obj)
+	})
+}
+
+// writeFile creates the file at p - including missing parent directories - and writes to it.
+func writeFile(p string, write func(io.Writer) error) (err error) {
+	if err = os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(p)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, f.Close())
+	}()
+	return write(f)
+}
@@ -0,0 +1,87 @@
+package cldf
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDataset_Write(t *testing.T) {
+	for _, p := range []string{
+		"StructureDataset-metadata.json",
+		"fks/Generic-metadata.json",
+		"dialect/Generic-metadata.json",
+	} {
+		t.Run(p, func(t *testing.T) {
+			ds, err := GetLoadedDataset(filepath.Join("testdata", p), false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			dir := t.TempDir()
+			if err = ds.Write(dir); err != nil {
+				t.Fatal(err)
+			}
+			written, err := GetLoadedDataset(filepath.Join(dir, filepath.Base(p)), false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(ds.Metadata, written.Metadata) {
+				t.Errorf(`problem: %v vs %v`, ds.Metadata, written.Metadata)
+			}
+			if !reflect.DeepEqual(ds.order, written.order) {
+				t.Errorf(`problem: %v vs %v`, ds.order, written.order)
+			}
+			for name, tbl := range ds.Tables {
+				if !reflect.DeepEqual(tbl.Data, written.Tables[name].Data) {
+					t.Errorf(`problem: %v vs %v`, tbl.Data, written.Tables[name].Data)
+				}
+			}
+			if ds.Sources != nil && !reflect.DeepEqual(ds.Sources.Items, written.Sources.Items) {
+				t.Errorf(`problem: %v vs %v`, ds.Sources.Items, written.Sources.Items)
+			}
+		})
+	}
+}
+
+func TestDataset_Write_sourceContexts(t *testing.T) {
+	b, err := NewBuilder("StructureDataset")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = b.AddComponent("ValueTable"); err != nil {
+		t.Fatal(err)
+	}
+	b.AddSource(&Source{Id: "Meier2005", Type: "book", Fields: map[string]string{"title": "T"}})
+	row := map[string]string{"ID": "1", "Language_ID": "l1", "Parameter_ID": "p1", "Value": "x", "Source": "Meier2005[12][23-24];Meier2005[3]"}
+	if err = b.AddRow("ValueTable", row); err != nil {
+		t.Fatal(err)
+	}
+	ds, err := b.Dataset()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dirs := []string{t.TempDir(), t.TempDir()}
+	if err = ds.Write(dirs[0]); err != nil {
+		t.Fatal(err)
+	}
+	written, err := GetLoadedDataset(filepath.Join(dirs[0], "StructureDataset-metadata.json"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = written.Write(dirs[1]); err != nil {
+		t.Fatal(err)
+	}
+	var csvs []string
+	for _, dir := range dirs {
+		content, err := os.ReadFile(filepath.Join(dir, "values.csv"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		csvs = append(csvs, string(content))
+	}
+	if !strings.Contains(csvs[0], "Meier2005[12][23-24];Meier2005[3]") || csvs[0] != csvs[1] {
+		t.Errorf(`problem: %q vs %q`, csvs[0], csvs[1])
+	}
+}
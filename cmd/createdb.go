@@ -1,7 +1,10 @@
 package cmd
 
 import (
+	"cmp"
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"gocldf/cldf"
 	"gocldf/internal/dbutil"
@@ -9,22 +12,47 @@ import (
 	"io"
 	"maps"
 	"slices"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
 
-func createdb(out io.Writer, mdPath string, dbPath string, overwrite bool, noChecks bool, bibtexFieldsets ...string) (err error) {
-	dbPath, err = pathutil.GetFreshPath(dbPath, overwrite)
-	if err != nil {
+// createdb loads the dataset at mdPath into a SQLite database. If appendDb is true, the dataset
+// is added to the - possibly existing - database at dbPath, with table names prefixed with
+// namespace or - if empty - the identifier of the dataset.
+func createdb(ctx context.Context, out io.Writer, mdPath string, dbPath string, overwrite bool, noChecks bool, withUrls bool, appendDb bool, namespace string, bibtexFieldsets ...string) (err error) {
+	if overwrite && appendDb {
+		return errors.New("cannot overwrite a database in append mode")
+	}
+	if err = checkNamespace(namespace); err != nil {
 		return err
 	}
-	ds, err := cldf.GetLoadedDataset(mdPath, noChecks, bibtexFieldsets...)
+	if !appendDb {
+		dbPath, err = pathutil.GetFreshPath(dbPath, overwrite)
+		if err != nil {
+			return err
+		}
+	}
+	// We don't load the data into memory, but stream it into the database table by table.
+	ds, err := cldf.Discover(mdPath, bibtexFieldsets...)
 	if err != nil {
 		return err
 	}
+	ds.UrlColumns = withUrls
+	if appendDb {
+		ds.Namespace = cmp.Or(namespace, ds.Identifier())
+		if ds.Namespace == "" {
+			return errors.New("dataset has no rdf:ID or dc:identifier, specify a namespace")
+		}
+	}
 	err_ := dbutil.WithDatabase(dbPath, func(database *sql.DB) error {
 		return dbutil.WithTransaction(database, func(tx *sql.Tx) (err error) {
-			schema, tableData, err := ds.ToSqlite(noChecks)
+			if appendDb {
+				if err = checkRegistry(tx, ds.Namespace); err != nil {
+					return err
+				}
+			}
+			schema, tableRows, err := ds.StreamToSqlite(ctx, noChecks)
 			if err != nil {
 				return err
 			}
@@ -32,8 +60,14 @@ func createdb(out io.Writer, mdPath string, dbPath string, overwrite bool, noChe
 			if err != nil {
 				return err
 			}
-			for _, tData := range tableData { // ... and the data.
-				err = dbutil.BatchInsert(tx, tData.TableName, tData.ColNames, tData.Rows)
+			for _, tRows := range tableRows { // ... and the data.
+				if appendDb && tRows.TableName == "SourceTable" {
+					// The shared SourceTable may lack fields of the sources of this dataset.
+					if err = dbutil.AddMissingColumns(tx, tRows.TableName, tRows.ColNames); err != nil {
+						return err
+					}
+				}
+				err = dbutil.BatchInsertSeq(tx, tRows.TableName, tRows.ColNames, tRows.Rows)
 				if err != nil {
 					return err
 				}
@@ -63,24 +97,69 @@ func createdb(out io.Writer, mdPath string, dbPath string, overwrite bool, noChe
 		return err
 	}
 	for _, tbl := range ds.Tables {
-		if !slices.Contains(tableNames, tbl.CanonicalName) {
-			return fmt.Errorf("table %s not found in database", tbl.CanonicalName)
+		if !slices.Contains(tableNames, ds.SqlTableName(tbl)) {
+			return fmt.Errorf("table %s not found in database", ds.SqlTableName(tbl))
 		}
 	}
 	fmt.Fprintf(out, "Loaded dataset at\n%v\ninto SQLite database at\n%v\n", mdPath, dbPath)
 	return nil
 }
 
+// checkNamespace makes sure a namespace can be used unquoted as prefix of SQL table names, i.e.
+// consists of ASCII letters, digits and underscores - like the identifiers of datasets.
+func checkNamespace(namespace string) error {
+	if strings.ContainsFunc(namespace, func(r rune) bool {
+		return r != '_' && (r < '0' || r > '9') && (r < 'a' || r > 'z') && (r < 'A' || r > 'Z')
+	}) {
+		return fmt.Errorf("invalid namespace %q: must consist of ASCII letters, digits and underscores", namespace)
+	}
+	return nil
+}
+
+// checkRegistry makes sure a database can take a dataset with the given namespace, i.e. is empty
+// or has been created in append mode and does not contain the dataset yet.
+func checkRegistry(tx *sql.Tx, namespace string) error {
+	var nTables, nRegistry int
+	err := tx.QueryRow(
+		"SELECT count(*), count(CASE WHEN name = 'dataset' THEN 1 END) FROM sqlite_master WHERE type='table';",
+	).Scan(&nTables, &nRegistry)
+	if err != nil {
+		return err
+	}
+	if nTables == 0 {
+		return nil
+	}
+	if nRegistry == 0 {
+		return errors.New("database has not been created in append mode")
+	}
+	var n int
+	if err = tx.QueryRow("SELECT count(*) FROM dataset WHERE id = ?;", namespace).Scan(&n); err != nil {
+		return err
+	}
+	if n > 0 {
+		return fmt.Errorf("dataset %v already in database", namespace)
+	}
+	return nil
+}
+
 var (
 	overwrite       bool
 	noChecks        bool
+	withUrls        bool
+	appendDb        bool
+	dbNamespace     string
 	bibtexFieldsets []string
 )
 var createdbCmd = &cobra.Command{
 	Use:   "createdb DATASET DB_PATH",
 	Short: "Load CLDF dataset into a SQLite database",
-	Long:  "",
-	Args:  cobra.ExactArgs(2),
+	Long: `Load CLDF dataset into a SQLite database
+
+With --append, multiple datasets can be loaded into one database. Table names are then prefixed
+with the rdf:ID or dc:identifier of each dataset - or the namespace given with --namespace -, e.g.
+wals_ValueTable, datasets are listed in a table "dataset" and sources of all datasets are stored
+in one SourceTable, keyed by dataset and ID.`,
+	Args: cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		for _, v := range bibtexFieldsets {
 			_, ok := cldf.BibtexFieldsets[v]
@@ -88,18 +167,31 @@ var createdbCmd = &cobra.Command{
 				return fmt.Errorf("invalid bibtex fieldset %q: must be one of %v", v, slices.Collect(maps.Keys(cldf.BibtexFieldsets)))
 			}
 		}
-		return createdb(cmd.OutOrStdout(), args[0], args[1], overwrite, noChecks, bibtexFieldsets...)
+		return createdb(cmd.Context(), cmd.OutOrStdout(), args[0], args[1], overwrite, noChecks, withUrls, appendDb, dbNamespace, bibtexFieldsets...)
 	},
 }
 
 func init() {
-	createdbCmd.Flags().BoolVarP(&overwrite, "overwrite", "f", false, "Overwrite SQLite file if exists")
+	createdbCmd.Flags().BoolVarP(&overwrite, "overwrite", "f", false, "Overwrite SQLite file if exists, not allowed with --append")
 	createdbCmd.Flags().BoolVarP(
 		&noChecks,
 		"nochecks",
 		"n",
 		false,
 		"Do not enforce column constraints on read and write. Can be used to speed up db creation for datasets with known validity.")
+	createdbCmd.Flags().BoolVarP(
+		&withUrls,
+		"urls",
+		"",
+		false,
+		"Add columns with the URLs expanded from aboutUrl and valueUrl templates, e.g. Glottocode_url.")
+	createdbCmd.Flags().BoolVarP(
+		&appendDb,
+		"append",
+		"a",
+		false,
+		"Add the dataset to a - possibly existing - database holding multiple datasets, prefixing table names with the dataset identifier.")
+	createdbCmd.Flags().StringVarP(&dbNamespace, "namespace", "", "", "Prefix for table names in append mode, defaults to rdf:ID or dc:identifier of the dataset.")
 	createdbCmd.Flags().StringSliceVarP(&bibtexFieldsets, "bibtexfields", "", []string{}, "Restrict loaded fields for SourceTable to standard BibTeX fieldsets (bibtex or biblatex).")
 	rootCmd.AddCommand(createdbCmd)
 }
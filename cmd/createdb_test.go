@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"gocldf/internal/dbutil"
 	"path/filepath"
@@ -39,3 +40,94 @@ func TestCreatedb(t *testing.T) {
 		t.Errorf(`problem: %v vs. %v`, countRefs, 3)
 	}
 }
+
+func TestCreatedb_urls(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.sqlite")
+	err := createdb(context.Background(), new(bytes.Buffer), "../cldf/testdata/StructureDataset-metadata.json", dbPath, false, false, true, false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var glottologUrl string
+	err = dbutil.QueryDatabase(
+		dbPath,
+		"SELECT cldf_glottocode_url FROM LanguageTable WHERE cldf_id = ?;",
+		func(rows *sql.Rows) error {
+			return rows.Scan(&glottologUrl)
+		}, "Kharia_SM")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "http://glottolog.org/resource/languoid/id/khar1287"
+	if glottologUrl != expected {
+		t.Errorf(`problem: %v vs. %v`, glottologUrl, expected)
+	}
+}
+
+func TestCreatedb_append(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.sqlite")
+	for _, args := range []struct{ mdPath, namespace string }{
+		{"../cldf/testdata/StructureDataset-metadata.json", ""},
+		{"../cldf/testdata/dialect/Generic-metadata.json", "dialect"},
+		{"../cldf/testdata/StructureDataset-metadata.json", "copy"},
+	} {
+		err := createdb(context.Background(), new(bytes.Buffer), args.mdPath, dbPath, false, false, false, true, args.namespace)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := createdb(context.Background(), new(bytes.Buffer), "../cldf/testdata/StructureDataset-metadata.json", dbPath, false, false, false, true, "")
+	if err == nil || !strings.Contains(err.Error(), "already in database") {
+		t.Errorf(`problem: %v`, err)
+	}
+	err = createdb(context.Background(), new(bytes.Buffer), "../cldf/testdata/dialect/Generic-metadata.json", dbPath, false, false, false, true, "")
+	if err == nil || !strings.Contains(err.Error(), "specify a namespace") {
+		t.Errorf(`problem: %v`, err)
+	}
+
+	var datasets, countRefs int
+	err = dbutil.QueryDatabase(dbPath, "SELECT count(*) FROM dataset;", func(rows *sql.Rows) error {
+		return rows.Scan(&datasets)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if datasets != 3 {
+		t.Errorf(`problem: %v vs. %v`, datasets, 3)
+	}
+	err = dbutil.QueryDatabase(
+		dbPath,
+		"SELECT count(*) FROM copy_ValueTable as v, copy_ValueTable_SourceTable as vs, SourceTable as s WHERE s.doi = ? AND v.cldf_id = vs.ValueTable_cldf_id AND vs.SourceTable_id = s.id AND vs.SourceTable_dataset = s.dataset AND s.dataset = 'copy';",
+		func(rows *sql.Rows) error {
+			return rows.Scan(&countRefs)
+		}, "10.1515/jsall-2017-0008")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if countRefs != 812 {
+		t.Errorf(`problem: %v vs. %v`, countRefs, 812)
+	}
+	var langs int
+	err = dbutil.QueryDatabase(dbPath, "SELECT count(*) FROM `dialect_items.csv`;", func(rows *sql.Rows) error {
+		return rows.Scan(&langs)
+	})
+	if err != nil || langs == 0 {
+		t.Errorf(`problem: %v %v`, langs, err)
+	}
+}
+
+func TestCreatedb_invalidFlags(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.sqlite")
+	for _, args := range []struct {
+		overwrite, appendDb bool
+		namespace, expected string
+	}{
+		{true, true, "", "append mode"},
+		{false, true, "my-ds", "invalid namespace"},
+		{false, true, "x; DROP TABLE dataset", "invalid namespace"},
+	} {
+		err := createdb(context.Background(), new(bytes.Buffer), "../cldf/testdata/StructureDataset-metadata.json", dbPath, args.overwrite, false, false, args.appendDb, args.namespace)
+		if err == nil || !strings.Contains(err.Error(), args.expected) {
+			t.Errorf(`problem: %v`, err)
+		}
+	}
+}
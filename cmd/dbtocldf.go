@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"gocldf/cldf"
+	"gocldf/internal/dbutil"
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+func dbtocldf(out io.Writer, dbPath string, outDir string, namespace string) error {
+	var ds *cldf.Dataset
+	err := dbutil.WithDatabase(dbPath, func(db *sql.DB) (err error) {
+		ds, err = cldf.FromSqlite(db, namespace, outDir)
+		return err
+	}, true, false)
+	if err != nil {
+		return err
+	}
+	if err = ds.Write(outDir); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Wrote dataset from SQLite database at\n%v\nto\n%v\n", dbPath, ds.MetadataPath)
+	return nil
+}
+
+var dbNamespaceToExport string
+var dbtocldfCmd = &cobra.Command{
+	Use:   "dbtocldf DB OUTDIR",
+	Short: "Rebuild a CLDF dataset from a SQLite database",
+	Long: `Rebuild a CLDF dataset from a SQLite database created with createdb, i.e. write the
+metadata, the data - including edits made in the database - and the sources to OUTDIR.
+
+List-valued columns implemented as association tables are re-joined, with the context of
+source references appended in square brackets. For databases holding multiple datasets,
+the dataset must be selected with --namespace.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return dbtocldf(cmd.OutOrStdout(), args[0], args[1], dbNamespaceToExport)
+	},
+}
+
+func init() {
+	dbtocldfCmd.Flags().StringVarP(&dbNamespaceToExport, "namespace", "", "", "The namespace of the dataset in databases holding multiple datasets")
+	rootCmd.AddCommand(dbtocldfCmd)
+}
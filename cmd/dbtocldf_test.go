@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDbtocldf(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "test.sqlite")
+	err := createdb(context.Background(), new(bytes.Buffer), "../cldf/testdata/StructureDataset-metadata.json", dbPath, false, false, false, false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"dbtocldf", dbPath, filepath.Join(dir, "out")})
+	rootCmd.Execute()
+
+	expected := "StructureDataset-metadata.json"
+	if !strings.Contains(actual.String(), expected) {
+		t.Errorf(`problem: "%q"" not in "%q""`, expected, actual.String())
+	}
+	values, err := os.ReadFile(filepath.Join(dir, "out", "values.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = "Kharia_SM-1,Kharia_SM,B,1,B-1,,Peterson2017"
+	if !strings.Contains(string(values), expected) {
+		t.Errorf(`problem: "%q"" not in "%q""`, expected, string(values))
+	}
+	if _, err = os.Stat(filepath.Join(dir, "out", "sources.bib")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDbtocldf_namespace(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "test.sqlite")
+	for _, namespace := range []string{"a", "b"} {
+		err := createdb(context.Background(), new(bytes.Buffer), "../cldf/testdata/StructureDataset-metadata.json", dbPath, false, false, false, true, namespace)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := dbtocldf(new(bytes.Buffer), dbPath, filepath.Join(dir, "out"), "")
+	if err == nil || !strings.Contains(err.Error(), "multiple datasets") {
+		t.Errorf(`problem: %v`, err)
+	}
+	err = dbtocldf(new(bytes.Buffer), dbPath, filepath.Join(dir, "out"), "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "out", "languages.csv")); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"gocldf/cldf"
+	"io"
+	"maps"
+	"slices"
+
+	"github.com/spf13/cobra"
+)
+
+func dump(ctx context.Context, out io.Writer, path string, dialect string, noChecks bool, namespace string) error {
+	d, ok := cldf.SqlDialects[dialect]
+	if !ok {
+		return fmt.Errorf("invalid SQL dialect %q: must be one of %v", dialect, slices.Sorted(maps.Keys(cldf.SqlDialects)))
+	}
+	if err := checkNamespace(namespace); err != nil {
+		return err
+	}
+	ds, err := cldf.Discover(path)
+	if err != nil {
+		return err
+	}
+	ds.Namespace = namespace
+	return ds.WriteSql(ctx, out, d, noChecks)
+}
+
+var (
+	sqlDialect    string
+	dumpNoChecks  bool
+	dumpNamespace string
+)
+var dumpCmd = &cobra.Command{
+	Use:   "dump DATASET",
+	Short: "Write a dataset as SQL script",
+	Long: `Write a SQL script creating the tables of a dataset and inserting its data, in the SQL
+dialect of SQLite, PostgreSQL or DuckDB. The script has the same schema as a database created
+with createdb - including the bookkeeping tables read by dbtocldf.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return dump(cmd.Context(), cmd.OutOrStdout(), args[0], sqlDialect, dumpNoChecks, dumpNamespace)
+	},
+}
+
+func init() {
+	dumpCmd.Flags().StringVarP(&sqlDialect, "dialect", "d", "sqlite", "The SQL dialect, one of sqlite, postgres and duckdb")
+	dumpCmd.Flags().BoolVarP(&dumpNoChecks, "nochecks", "n", false, "Do not check the data and do not add CHECK constraints")
+	dumpCmd.Flags().StringVarP(&dumpNamespace, "namespace", "", "", "Prefix for table names, to load the script into a database holding multiple datasets")
+	rootCmd.AddCommand(dumpCmd)
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestDump(t *testing.T) {
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"dump", "--dialect", "postgres", "../cldf/testdata/StructureDataset-metadata.json"})
+	rootCmd.Execute()
+
+	expected := `CREATE TABLE IF NOT EXISTS "ValueTable_SourceTable"`
+	if !strings.Contains(actual.String(), expected) {
+		t.Errorf(`problem: "%q"" not in "%q""`, expected, actual.String())
+	}
+	expected = "COMMIT;"
+	if !strings.HasSuffix(actual.String(), expected+"\n") {
+		t.Errorf(`problem: "%q"" not at end of output`, expected)
+	}
+}
+
+func TestDump_invalidDialect(t *testing.T) {
+	err := dump(context.Background(), new(bytes.Buffer), "../cldf/testdata/StructureDataset-metadata.json", "oracle", false, "")
+	if err == nil || !strings.Contains(err.Error(), "duckdb") {
+		t.Errorf(`problem: %v`, err)
+	}
+}
+
+func TestDump_invalidNamespace(t *testing.T) {
+	err := dump(context.Background(), new(bytes.Buffer), "../cldf/testdata/StructureDataset-metadata.json", "sqlite", false, "my.ds")
+	if err == nil || !strings.Contains(err.Error(), "invalid namespace") {
+		t.Errorf(`problem: %v`, err)
+	}
+}
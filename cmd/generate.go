@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"gocldf/cldf"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/spf13/cobra"
+)
+
+var codeTemplate = template.Must(template.New("code").Parse(`// Code generated by gocldf generate from {{ .Source }}; DO NOT EDIT.
+
+package {{ .Package }}
+
+import (
+{{- range .Imports }}
+	"{{ . }}"
+{{- end }}
+)
+{{ range .Structs }}
+// {{ .Name }} is a row of {{ .Table }}.
+type {{ .Name }} struct {
+{{- range .Fields }}
+	{{ .Name }} {{ .Type }} ` + "`" + `cldf:"{{ .Column }}"` + "`" + `
+{{- end }}
+}
+{{ end }}
+// Dataset holds the typed rows of all tables of the dataset.
+type Dataset struct {
+	*cldf.Dataset
+{{- range .Structs }}
+	{{ .Plural }} []{{ .Name }}
+{{- end }}
+}
+
+// Load loads the dataset at path - see cldf.Discover - and unmarshals the rows of all tables.
+func Load(path string) (*Dataset, error) {
+	ds, err := cldf.GetLoadedDataset(path, false)
+	if err != nil {
+		return nil, err
+	}
+	res := &Dataset{Dataset: ds}
+{{- range .Structs }}
+	if res.{{ .Plural }}, err = Load{{ .Plural }}(ds); err != nil {
+		return nil, err
+	}
+{{- end }}
+	return res, nil
+}
+{{ range .Structs }}
+// Load{{ .Plural }} unmarshals the rows of {{ .Table }}.
+func Load{{ .Plural }}(ds *cldf.Dataset) ([]{{ .Name }}, error) {
+	return cldf.Rows[{{ .Name }}](ds.Tables["{{ .Key }}"])
+}
+{{ end }}`))
+
+type codeField struct {
+	Name   string
+	Type   string
+	Column string
+}
+
+type codeStruct struct {
+	Name   string
+	Plural string
+	Table  string // The url of the table
+	Key    string // The key of the table in Dataset.Tables
+	Fields []codeField
+}
+
+// goIdentifier turns a name into an exported Go identifier, e.g. "Language_ID" into "LanguageID".
+func goIdentifier(name string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		runes := []rune(part)
+		b.WriteRune(unicode.ToUpper(runes[0]))
+		b.WriteString(string(runes[1:]))
+	}
+	res := b.String()
+	if res == "" || unicode.IsDigit([]rune(res)[0]) {
+		res = "X" + res
+	}
+	return res
+}
+
+func plural(name string) string {
+	switch {
+	case strings.HasSuffix(name, "s"):
+		return name
+	case len(name) > 1 && strings.HasSuffix(name, "y") && !strings.ContainsRune("aeiou", rune(name[len(name)-2])):
+		return name[:len(name)-1] + "ies"
+	}
+	return name + "s"
+}
+
+// goType returns the Go type for the values of a column as returned by Table.Unmarshal. Values
+// of nullable columns are pointers, unless the type can represent a missing value anyway.
+func goType(tbl *cldf.Table, col *cldf.Column) string {
+	if col.Separator != "" {
+		if col.CanonicalName == "cldf_source" {
+			return "[]cldf.SourceReference"
+		}
+		return "[]string"
+	}
+	typ := col.Datatype.GoType()
+	nullable := !col.Required && !slices.Contains(tbl.PrimaryKey, col.Name)
+	if nullable && !strings.HasPrefix(typ, "*") && !strings.HasPrefix(typ, "[]") && typ != "any" {
+		typ = "*" + typ
+	}
+	return typ
+}
+
+func generate(out io.Writer, path string, pkg string) error {
+	ds, err := cldf.Discover(path)
+	if err != nil {
+		return err
+	}
+
+	data := struct {
+		Source  string
+		Package string
+		Imports []string
+		Structs []codeStruct
+	}{Source: path, Package: pkg, Imports: []string{"gocldf/cldf"}}
+	var names []string
+	for _, key := range slices.Sorted(maps.Keys(ds.Tables)) {
+		tbl := ds.Tables[key]
+		name := goIdentifier(strings.TrimSuffix(tbl.Url, ".csv"))
+		if tbl.Comp != "" {
+			name = goIdentifier(strings.TrimSuffix(tbl.CanonicalName, "Table"))
+		}
+		for slices.Contains(names, name) || name == "Dataset" {
+			name += "Row"
+		}
+		names = append(names, name)
+		s := codeStruct{Name: name, Plural: plural(name), Table: tbl.Url, Key: key}
+		var fieldNames []string
+		for _, col := range tbl.Columns {
+			field := codeField{Name: goIdentifier(col.Name), Type: goType(tbl, col), Column: col.Name}
+			for slices.Contains(fieldNames, field.Name) {
+				field.Name += "_"
+			}
+			fieldNames = append(fieldNames, field.Name)
+			for prefix, imp := range map[string]string{"time.": "time", "url.": "net/url"} {
+				if strings.Contains(field.Type, prefix) && !slices.Contains(data.Imports, imp) {
+					data.Imports = append(data.Imports, imp)
+				}
+			}
+			s.Fields = append(s.Fields, field)
+		}
+		data.Structs = append(data.Structs, s)
+	}
+	slices.Sort(data.Imports)
+
+	var buf bytes.Buffer
+	if err = codeTemplate.Execute(&buf, data); err != nil {
+		return err
+	}
+	code, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("invalid generated code: %w", err)
+	}
+	_, err = out.Write(code)
+	return err
+}
+
+var (
+	outputPath  string
+	packageName string
+)
+var generateCmd = &cobra.Command{
+	Use:   "generate DATASET",
+	Short: "Generate Go code for typed access to the tables of a dataset",
+	Long: `Generate Go source with a struct per table - with fields typed according to the column
+datatypes - and functions to load the rows of the dataset into these structs.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if outputPath != "" {
+			return generateFile(outputPath, args[0], packageName)
+		}
+		return generate(cmd.OutOrStdout(), args[0], packageName)
+	},
+}
+
+// generateFile writes the generated code to a temporary file next to p, which replaces p only
+// if the code has been generated successfully.
+func generateFile(p string, path string, pkg string) (err error) {
+	f, err := os.CreateTemp(filepath.Dir(p), "."+filepath.Base(p)+"-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			err = errors.Join(err, os.Remove(f.Name()))
+		}
+	}()
+	if err = generate(f, path, pkg); err != nil {
+		return errors.Join(err, f.Close())
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(f.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), p)
+}
+
+func init() {
+	generateCmd.Flags().StringVarP(&outputPath, "output", "o", "", "Write the code to this file instead of stdout")
+	generateCmd.Flags().StringVarP(&packageName, "package", "p", "dataset", "Name of the package of the generated code")
+	rootCmd.AddCommand(generateCmd)
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_generate(t *testing.T) {
+	actual := new(bytes.Buffer)
+	if err := generate(actual, "../cldf/testdata/StructureDataset-metadata.json", "wals"); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"package wals",
+		"type Language struct",
+		"Latitude     *float64 `cldf:\"Latitude\"`",
+		"Source      []cldf.SourceReference `cldf:\"Source\"`",
+		"func LoadLanguages(ds *cldf.Dataset) ([]Language, error)",
+	} {
+		if !strings.Contains(actual.String(), expected) {
+			t.Errorf(`problem: "%q"" not in "%q""`, expected, actual.String())
+		}
+	}
+}
+
+func Test_goIdentifier(t *testing.T) {
+	for in, expected := range map[string]string{
+		"Language_ID":  "LanguageID",
+		"ISO639P3code": "ISO639P3code",
+		"1st value":    "X1stValue",
+		"":             "X",
+	} {
+		if actual := goIdentifier(in); actual != expected {
+			t.Errorf(`problem: %v != %v`, actual, expected)
+		}
+	}
+}
+
+func Test_plural(t *testing.T) {
+	for in, expected := range map[string]string{
+		"Language": "Languages",
+		"Entry":    "Entries",
+		"Day":      "Days",
+		"Forms":    "Forms",
+		"y":        "ys",
+	} {
+		if actual := plural(in); actual != expected {
+			t.Errorf(`problem: %v != %v`, actual, expected)
+		}
+	}
+}
+
+func Test_generate_nameCollisions(t *testing.T) {
+	mdPath := filepath.Join(t.TempDir(), "Generic-metadata.json")
+	md := `{
+  "@context": "http://www.w3.org/ns/csvw",
+  "dc:conformsTo": "http://cldf.clld.org/v1.0/terms.rdf#Generic",
+  "tables": [
+    {"url": "A.csv", "tableSchema": {"columns": [{"name": "ID"}]}},
+    {"url": "a.csv", "tableSchema": {"columns": [{"name": "ID"}]}},
+    {"url": "ARow.csv", "tableSchema": {"columns": [{"name": "ID"}]}}
+  ]
+}`
+	if err := os.WriteFile(mdPath, []byte(md), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	actual := new(bytes.Buffer)
+	if err := generate(actual, mdPath, "ds"); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"type A struct", "type ARow struct", "type ARowRow struct"} {
+		if strings.Count(actual.String(), expected+" {") != 1 {
+			t.Errorf(`problem: %q not in output once`, expected)
+		}
+	}
+}
+
+func Test_generateFile(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go tool not available")
+	}
+	root, err := filepath.Abs("..")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The generated code is built in a module of its own, using this module via replace.
+	dir := t.TempDir()
+	goMod := "module generated\n\ngo 1.23\n\nrequire gocldf v0.0.0\n\nreplace gocldf => " + root + "\n"
+	if err = os.WriteFile(filepath.Join(dir, "go.mod"), []byte(goMod), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	goSum, err := os.ReadFile(filepath.Join(root, "go.sum"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(dir, "go.sum"), goSum, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p := filepath.Join(dir, "dataset.go")
+	if err = generateFile(p, "../cldf/testdata/StructureDataset-metadata.json", "dataset"); err != nil {
+		t.Fatal(err)
+	}
+	goVet := exec.Command("go", "vet", ".")
+	goVet.Dir = dir
+	goVet.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOPROXY=off")
+	if out, err := goVet.CombinedOutput(); err != nil {
+		t.Errorf(`problem: %v %s`, err, out)
+	}
+
+	// Failing to generate code must leave the existing file alone.
+	code, _ := os.ReadFile(p)
+	if err = generateFile(p, "../cldf/testdata/missing-metadata.json", "dataset"); err == nil {
+		t.Errorf(`problem: expected error`)
+	}
+	if actual, _ := os.ReadFile(p); !bytes.Equal(actual, code) {
+		t.Errorf(`problem: file changed`)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(dir, ".dataset.go-*")); len(matches) > 0 {
+		t.Errorf(`problem: temporary files left: %v`, matches)
+	}
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"gocldf/cldf"
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+func geojson(out io.Writer, errOut io.Writer, path string, properties []string, parameter string) error {
+	ds, err := cldf.GetLoadedDataset(path, false)
+	if err != nil {
+		return err
+	}
+
+	res, errs, err := ds.GeoJSON(properties, parameter)
+	if err != nil {
+		return err
+	}
+	for _, e := range errs {
+		fmt.Fprintln(errOut, "skipping language:", e)
+	}
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(res)
+}
+
+var (
+	geoProperties []string
+	geoParameter  string
+)
+var geojsonCmd = &cobra.Command{
+	Use:   "geojson DATASET",
+	Short: "Export the languages of a dataset as GeoJSON",
+	Long: `Export the languages with coordinates as GeoJSON FeatureCollection of points, optionally with
+the values for a parameter. Languages with invalid coordinates are skipped and reported.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return geojson(cmd.OutOrStdout(), cmd.ErrOrStderr(), args[0], geoProperties, geoParameter)
+	},
+}
+
+func init() {
+	geojsonCmd.Flags().StringSliceVarP(&geoProperties, "properties", "p", []string{"name"}, "LanguageTable columns - specified by name or CLDF property - to add as feature properties")
+	geojsonCmd.Flags().StringVar(&geoParameter, "parameter", "", "ID of a parameter whose values are added as feature properties")
+	rootCmd.AddCommand(geojsonCmd)
+}
@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func Test_geojson(t *testing.T) {
+	actual, errOut := new(bytes.Buffer), new(bytes.Buffer)
+	err := geojson(actual, errOut, "../cldf/testdata/StructureDataset-metadata.json", []string{"name"}, "B")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{`"type": "FeatureCollection"`, `"name": "Kharia"`, `"code": "B-1"`} {
+		if !strings.Contains(actual.String(), expected) {
+			t.Errorf(`problem: "%q"" not in "%q""`, expected, actual.String())
+		}
+	}
+	if errOut.Len() > 0 {
+		t.Errorf(`problem: %v`, errOut.String())
+	}
+}
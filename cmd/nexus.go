@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"gocldf/cldf"
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+func nexus(out io.Writer, path string, opts cldf.NexusOptions) error {
+	ds, err := cldf.GetLoadedDataset(path, false)
+	if err != nil {
+		return err
+	}
+	return ds.WriteNexus(out, opts)
+}
+
+var nexusOptions cldf.NexusOptions
+var nexusCmd = &cobra.Command{
+	Use:   "nexus DATASET",
+	Short: "Export a character matrix as NEXUS file",
+	Long: `Export a language by parameter matrix - built from ValueTable and CodeTable - or a cognate set
+presence/absence matrix - built from CognateTable - as NEXUS file.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return nexus(cmd.OutOrStdout(), args[0], nexusOptions)
+	},
+}
+
+func init() {
+	nexusCmd.Flags().BoolVar(&nexusOptions.Cognates, "cognates", false, "Build a presence/absence matrix of cognate sets")
+	nexusCmd.Flags().BoolVar(&nexusOptions.Binarize, "binarize", false, "Split parameters into one presence/absence character per code")
+	nexusCmd.Flags().StringVar(&nexusOptions.Label, "label", "name", "LanguageTable column - specified by name or CLDF property - to use for taxa labels")
+	nexusCmd.Flags().StringVar(&nexusOptions.Missing, "missing", "?", "Symbol for missing data")
+	nexusCmd.Flags().StringVar(&nexusOptions.Polymorphism, "polymorphism", cldf.Polymorphic, "How to write multiple states of a language: polymorphic, first or missing")
+	rootCmd.AddCommand(nexusCmd)
+}
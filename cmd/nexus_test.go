@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"bytes"
+	"gocldf/cldf"
+	"strings"
+	"testing"
+)
+
+func Test_nexus(t *testing.T) {
+	actual := new(bytes.Buffer)
+	err := nexus(actual, "../cldf/testdata/StructureDataset-metadata.json", cldf.NexusOptions{Polymorphism: cldf.FirstState})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "#NEXUS"
+	if !strings.HasPrefix(actual.String(), expected) {
+		t.Errorf(`problem: "%q"" not in "%q""`, expected, actual.String())
+	}
+	if err = nexus(actual, "../cldf/testdata/StructureDataset-metadata.json", cldf.NexusOptions{Polymorphism: "x"}); err == nil {
+		t.Error(`problem: invalid polymorphism handling accepted`)
+	}
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"gocldf/cldf"
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+func pivot(out io.Writer, path string, opts cldf.PivotOptions, tsv bool) error {
+	ds, err := cldf.GetLoadedDataset(path, false)
+	if err != nil {
+		return err
+	}
+
+	header, rows, err := ds.Pivot(opts)
+	if err != nil {
+		return err
+	}
+	delimiter := ","
+	if tsv {
+		delimiter = "\t"
+	}
+	dialect, err := cldf.NewDialect(map[string]any{"dialect": map[string]any{"delimiter": delimiter}})
+	if err != nil {
+		return err
+	}
+	w := cldf.NewCsvWriter(out, dialect)
+	for _, record := range append([][]string{header}, rows...) {
+		if err = w.Write(record); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+var (
+	pivotOptions cldf.PivotOptions
+	tsv          bool
+)
+var pivotCmd = &cobra.Command{
+	Use:   "pivot DATASET",
+	Short: "Export the ValueTable as wide table",
+	Long:  "Export the ValueTable as CSV or TSV table with one row per language and one column per parameter.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return pivot(cmd.OutOrStdout(), args[0], pivotOptions, tsv)
+	},
+}
+
+func init() {
+	pivotCmd.Flags().StringVar(&pivotOptions.LanguageLabel, "language-label", "id", "LanguageTable column - specified by name or CLDF property - to use as row label")
+	pivotCmd.Flags().StringVar(&pivotOptions.ParameterLabel, "parameter-label", "id", "ParameterTable column - specified by name or CLDF property - to use as column header")
+	pivotCmd.Flags().BoolVar(&pivotOptions.Codes, "codes", false, "Use code IDs instead of values")
+	pivotCmd.Flags().StringVar(&pivotOptions.Separator, "separator", "; ", "String to join multiple values of a language for a parameter")
+	pivotCmd.Flags().BoolVar(&tsv, "tsv", false, "Write tab-separated values")
+	rootCmd.AddCommand(pivotCmd)
+}
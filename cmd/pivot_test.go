@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"bytes"
+	"gocldf/cldf"
+	"strings"
+	"testing"
+)
+
+func Test_pivot(t *testing.T) {
+	actual := new(bytes.Buffer)
+	err := pivot(actual, "../cldf/testdata/StructureDataset-metadata.json", cldf.PivotOptions{ParameterLabel: "name"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"Language\tGender/Noun classes\tEnclitic PL\t", "\nKharia_SM\t1\t1\t"} {
+		if !strings.Contains(actual.String(), expected) {
+			t.Errorf(`problem: "%q"" not in "%q""`, expected, actual.String())
+		}
+	}
+}
@@ -10,7 +10,10 @@ import (
 var rootCmd = &cobra.Command{
 	Use:   "gocldf",
 	Short: "gocldf is a cli tool to handle CLDF datasets",
-	Long:  "",
+	Long: `gocldf is a cli tool to handle CLDF datasets.
+
+Commands accept a DATASET as path to the metadata file, to a dataset directory, to a single
+CSV file (for metadata-free datasets) or to a zip archive of the dataset.`,
 	Run: func(cmd *cobra.Command, args []string) {
 	},
 }
@@ -12,8 +12,8 @@ import (
 	"github.com/spf13/cobra"
 )
 
-func stats(out io.Writer, mdPath string, withMetadata bool) error {
-	ds, err := cldf.GetLoadedDataset(mdPath, false)
+func stats(out io.Writer, path string, withMetadata bool) error {
+	ds, err := cldf.GetLoadedDataset(path, false)
 	if err != nil {
 		return err
 	}
@@ -25,3 +25,16 @@ func Test_ExecuteStats(t *testing.T) {
 		t.Errorf(`problem: "%q"" not in "%q""`, expected, actual.String())
 	}
 }
+
+func Test_statsDiscover(t *testing.T) {
+	for _, p := range []string{"../cldf/testdata", "../cldf/testdata/values.csv"} {
+		actual := new(bytes.Buffer)
+		if err := stats(actual, p, false); err != nil {
+			t.Fatal(err)
+		}
+		expected := `ValueTable`
+		if !strings.Contains(actual.String(), expected) {
+			t.Errorf(`problem: "%q"" not in "%q""`, expected, actual.String())
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"encoding/json"
+	"gocldf/cldf"
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+func tojson(out io.Writer, path string, minimal bool) error {
+	ds, err := cldf.GetLoadedDataset(path, false)
+	if err != nil {
+		return err
+	}
+
+	res, err := ds.ToJSON(minimal)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(res)
+}
+
+var minimal bool
+var tojsonCmd = &cobra.Command{
+	Use:   "tojson DATASET",
+	Short: "Convert the data of a dataset to JSON",
+	Long: `Convert the data of a dataset to JSON following the CSVW csv2json algorithm
+(see https://www.w3.org/TR/csv2json/), in standard or minimal mode.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return tojson(cmd.OutOrStdout(), args[0], minimal)
+	},
+}
+
+func init() {
+	tojsonCmd.Flags().BoolVar(&minimal, "minimal", false, "Only output the objects described by the rows")
+	rootCmd.AddCommand(tojsonCmd)
+}
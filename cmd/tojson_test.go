@@ -0,0 +1,18 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func Test_tojson(t *testing.T) {
+	actual := new(bytes.Buffer)
+	if err := tojson(actual, "../cldf/testdata/StructureDataset-metadata.json", true); err != nil {
+		t.Fatal(err)
+	}
+	expected := `"http://cldf.clld.org/v1.0/terms.rdf#languageReference": "Kharia_SM"`
+	if !strings.Contains(actual.String(), expected) {
+		t.Errorf(`problem: "%q"" not in "%q""`, expected, actual.String())
+	}
+}
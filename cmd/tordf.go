@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"gocldf/cldf"
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+func tordf(out io.Writer, path string, format string, minimal bool) error {
+	ds, err := cldf.GetLoadedDataset(path, false)
+	if err != nil {
+		return err
+	}
+	return ds.WriteRDF(out, format, minimal)
+}
+
+var (
+	rdfFormat  string
+	rdfMinimal bool
+)
+var tordfCmd = &cobra.Command{
+	Use:   "tordf DATASET",
+	Short: "Convert the data of a dataset to RDF",
+	Long: `Convert the data of a dataset to RDF - serialized as N-Triples or Turtle - following the
+CSVW csv2rdf algorithm (see https://www.w3.org/TR/csv2rdf/), in standard or minimal mode.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return tordf(cmd.OutOrStdout(), args[0], rdfFormat, rdfMinimal)
+	},
+}
+
+func init() {
+	tordfCmd.Flags().StringVarP(&rdfFormat, "format", "f", cldf.Turtle, "RDF format, ntriples or turtle")
+	tordfCmd.Flags().BoolVar(&rdfMinimal, "minimal", false, "Only output the triples describing the objects described by the rows")
+	rootCmd.AddCommand(tordfCmd)
+}
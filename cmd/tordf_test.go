@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func Test_tordf(t *testing.T) {
+	actual := new(bytes.Buffer)
+	if err := tordf(actual, "../cldf/testdata/StructureDataset-metadata.json", "turtle", false); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"@prefix cldf: <http://cldf.clld.org/v1.0/terms.rdf#> .",
+		`cldf:languageReference "Kharia_SM"`,
+		"csvw:describes",
+	} {
+		if !strings.Contains(actual.String(), expected) {
+			t.Errorf(`problem: "%q"" not in "%q""`, expected, actual.String())
+		}
+	}
+	if err := tordf(actual, "../cldf/testdata/StructureDataset-metadata.json", "xml", false); err == nil {
+		t.Error(`problem: unknown format accepted`)
+	}
+}
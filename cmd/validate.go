@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+	"gocldf/cldf"
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+func validate(out io.Writer, mdPath string, maxErrors int) error {
+	ds, err := cldf.Discover(mdPath)
+	if err != nil {
+		return err
+	}
+	errs, err := ds.Validate(maxErrors)
+	if err != nil {
+		return err
+	}
+	invalid := 0
+	for _, e := range errs {
+		fmt.Fprintln(out, e)
+		if !e.Warning {
+			invalid++
+		}
+	}
+	if invalid > 0 {
+		return fmt.Errorf("dataset at %v is invalid: %v errors", mdPath, invalid)
+	}
+	fmt.Fprintf(out, "Dataset at\n%v\nis valid\n", mdPath)
+	return nil
+}
+
+var maxErrors int
+var validateCmd = &cobra.Command{
+	Use:          "validate DATASET",
+	Short:        "Validate a CLDF dataset",
+	Long:         "Check datatypes, primary and foreign keys, source references and CLDF module conformance.",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return validate(cmd.OutOrStdout(), args[0], maxErrors)
+	},
+}
+
+func init() {
+	validateCmd.Flags().IntVarP(&maxErrors, "maxerrors", "m", 0, "Report at most this number of errors, not counting warnings (0 means no limit)")
+	rootCmd.AddCommand(validateCmd)
+}
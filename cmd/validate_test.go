@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func Test_ExecuteValidate(t *testing.T) {
+	actual := new(bytes.Buffer)
+	rootCmd.SetOut(actual)
+	rootCmd.SetErr(actual)
+	rootCmd.SetArgs([]string{"validate", "../cldf/testdata/StructureDataset-metadata.json"})
+	rootCmd.Execute()
+
+	expected := `is valid`
+	if !strings.Contains(actual.String(), expected) {
+		t.Errorf(`problem: "%q"" not in "%q""`, expected, actual.String())
+	}
+}
+
+func Test_validateInvalid(t *testing.T) {
+	actual := new(bytes.Buffer)
+	err := validate(actual, "../cldf/testdata/invalid/StructureDataset-metadata.json", 2)
+	if err == nil || !strings.Contains(err.Error(), "2 errors") {
+		t.Errorf(`problem: %v`, err)
+	}
+	expected := `languages.csv row 1 column Latitude`
+	if !strings.Contains(actual.String(), expected) {
+		t.Errorf(`problem: "%q"" not in "%q""`, expected, actual.String())
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"iter"
+	"slices"
 	"strings"
 
 	"gocldf/internal/pathutil"
@@ -63,6 +65,18 @@ func WithTransaction(db *sql.DB, fn func(tx *sql.Tx) error) (err error) {
 }
 
 func BatchInsert(tx *sql.Tx, tableName string, colNames []string, rows [][]any) error {
+	return BatchInsertSeq(tx, tableName, colNames, func(yield func([]any, error) bool) {
+		for _, row := range rows {
+			if !yield(row, nil) {
+				return
+			}
+		}
+	})
+}
+
+// BatchInsertSeq inserts the rows yielded by an iterator in batches, i.e. only holds one
+// batch of rows in memory at a time. The first error yielded by the iterator is returned.
+func BatchInsertSeq(tx *sql.Tx, tableName string, colNames []string, rows iter.Seq2[[]any, error]) error {
 	var (
 		version   string
 		maxParams = 900
@@ -89,27 +103,63 @@ func BatchInsert(tx *sql.Tx, tableName string, colNames []string, rows [][]any)
 	insert := strings.Join(insertSql, "")
 	rowPlaceholder := "(" + strings.Trim(strings.Repeat("?,", nCols), ",") + ")"
 
-	nBatches := 0
-	current := 0
-	for current < len(rows) {
-		nBatches++
-		nRows := batchSize
-		if nRows+current > len(rows) {
-			nRows = len(rows) - current
+	nRows := 0
+	args := make([]any, 0, batchSize*nCols)
+	flush := func() error {
+		if nRows == 0 {
+			return nil
 		}
-
 		allPlaceholders := strings.Repeat(rowPlaceholder+",", nRows)
 		allPlaceholders = strings.TrimSuffix(allPlaceholders, ",")
 
-		args := make([]any, 0, nRows*nCols)
-		for i := 0; i < nRows; i++ {
-			args = append(args, rows[current+i]...)
-		}
 		_, err := tx.Exec(insert+allPlaceholders+";", args...)
 		if err != nil {
 			return fmt.Errorf(`error executing "%v": %w`, insert, err)
 		}
-		current += nRows
+		nRows = 0
+		args = args[:0]
+		return nil
+	}
+	for row, err := range rows {
+		if err != nil {
+			return err
+		}
+		args = append(args, row...)
+		nRows++
+		if nRows == batchSize {
+			if err = flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return flush()
+}
+
+// AddMissingColumns adds TEXT columns to an existing table for all colNames the table lacks, e.g.
+// to insert rows with additional fields into a table shared by multiple datasets.
+func AddMissingColumns(tx *sql.Tx, tableName string, colNames []string) error {
+	rows, err := tx.Query(fmt.Sprintf("SELECT name FROM pragma_table_info('%v');", tableName))
+	if err != nil {
+		return err
+	}
+	var existing []string
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		existing = append(existing, name)
+	}
+	if err = errors.Join(rows.Err(), rows.Close()); err != nil {
+		return err
+	}
+	for _, col := range colNames {
+		if !slices.Contains(existing, col) {
+			if _, err = tx.Exec(fmt.Sprintf("ALTER TABLE `%v` ADD COLUMN `%v` TEXT;", tableName, col)); err != nil {
+				return err
+			}
+		}
 	}
 	return nil
 }
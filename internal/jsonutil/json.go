@@ -4,16 +4,24 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
+	"gocldf/internal/pathutil"
+	"io"
 	"strconv"
 )
 
-func ReadObject(path string) (map[string]any, error) {
-	data, err := os.ReadFile(path)
+// ReadObject reads a JSON object from the file at path, which may also be a file in a zip archive.
+func ReadObject(path string) (result map[string]any, err error) {
+	_, r, err := pathutil.Reader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = errors.Join(err, r.(io.Closer).Close())
+	}()
+	data, err := io.ReadAll(r.(io.Reader))
 	if err != nil {
 		return nil, err
 	}
-	var result map[string]any
 	err = json.Unmarshal(data, &result)
 	if err != nil {
 		return nil, err
@@ -2,21 +2,31 @@ package pathutil
 
 import (
 	"archive/zip"
-	"bytes"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"path"
+	"path/filepath"
+	"strings"
 )
 
 func GetFormattedSize(path string) (string, error) {
-	info, err := os.Stat(path)
-	if err != nil {
+	var size float64
+	if info, err := os.Stat(path); err == nil {
+		// Size() returns the size in bytes as an int64
+		size = float64(info.Size())
+	} else if archive, name, ok := splitArchive(path); ok {
+		f, err := archiveFile(archive, name)
+		if err != nil {
+			return "", err
+		}
+		size = float64(f.UncompressedSize64)
+	} else {
 		return "", err
 	}
-	// Size() returns the size in bytes as an int64
 	units := []string{"bytes", "KB", "MB", "GB"}
-	size := float64(info.Size())
 
 	for _, unit := range units {
 		if size < 1024.0 && size > -1024.0 {
@@ -27,13 +37,24 @@ func GetFormattedSize(path string) (string, error) {
 	return fmt.Sprintf("%g%v", size, "TB"), nil
 }
 
+// PathExists returns whether a file or directory exists at path, which may also be a path into
+// a zip archive, e.g. "dataset.zip/cldf/values.csv".
 func PathExists(path string) bool {
 	_, err := os.Stat(path)
 	if err == nil {
 		return true // Path exists
 	}
-	if errors.Is(err, os.ErrNotExist) {
-		return false // Specifically does not exist
+	if archive, name, ok := splitArchive(path); ok {
+		r, err := zip.OpenReader(archive)
+		if err != nil {
+			return false
+		}
+		defer r.Close()
+		for _, f := range r.File {
+			if f.Name == name || strings.HasPrefix(f.Name, name+"/") {
+				return true
+			}
+		}
 	}
 	return false
 }
@@ -52,55 +73,63 @@ func GetFreshPath(path string, overwrite bool) (p string, err error) {
 	return path, nil
 }
 
-func readZipped(fp string) (bytes []byte, err error) {
+// zipReader streams the first file in a zip archive and closes the archive when closed.
+type zipReader struct {
+	io.ReadCloser
+	archive *zip.ReadCloser
+}
+
+func (z *zipReader) Close() error {
+	return errors.Join(z.ReadCloser.Close(), z.archive.Close())
+}
+
+func openZipped(fp string) (io.ReadCloser, error) {
 	r, err := zip.OpenReader(fp)
 	if err != nil {
 		return nil, err
 	}
-	defer func(r *zip.ReadCloser) {
-		err = r.Close()
-	}(r)
-
-	var contentBytes []byte
-	for _, f := range r.File {
-		rc, err := f.Open()
-		if err != nil {
-			return nil, err
-		}
-		contentBytes, err = io.ReadAll(rc)
-		if err != nil {
-			return nil, err
-		}
-		err = rc.Close()
-		if err != nil {
-			return nil, err
-		} // Must close each file reader individually
-		break
+	if len(r.File) == 0 {
+		return nil, errors.Join(r.Close(), fmt.Errorf("empty zip archive %v", fp))
+	}
+	rc, err := r.File[0].Open()
+	if err != nil {
+		return nil, errors.Join(err, r.Close())
 	}
-	return contentBytes, nil
+	return &zipReader{rc, r}, nil
 }
 
 /*
 Reader may return an opened file which must be closed by the caller.
+Zipped files are not read into memory, but streamed from the archive. This applies to files
+zipped individually - i.e. p.zip - as well as to files in zip archives, addressed by paths
+like "dataset.zip/cldf/values.csv".
 
 Usage:
 
-	reader, err := pathutil.Reader(p)
+	_, reader, err := pathutil.Reader(p)
 	if err != nil {}
 	defer func(r any) {
-		switch r.(type) {
-		case *os.File:
-			err = r.(*os.File).Close()
+		if c, ok := r.(io.Closer); ok {
+			err = c.Close()
 		}
 	}(reader)
 */
 func Reader(p string) (pp string, r any, err error) {
-	if !PathExists(p) {
-		zippedBytes, err := readZipped(p + ".zip")
+	if _, err := os.Stat(p); err != nil {
+		if archive, name, ok := splitArchive(p); ok {
+			rc, err := openInArchive(archive, name)
+			if err == nil {
+				return p, rc, nil
+			}
+			if !errors.Is(err, fs.ErrNotExist) {
+				return "", nil, err
+			}
+		}
+		zipped, err := openZipped(p + ".zip")
 		if err != nil {
 			return "", nil, err
 		}
-		return p + ".zip", bytes.NewReader(zippedBytes), nil
+		return p + ".zip", zipped, nil
 	}
 	file, err := os.Open(p)
 	if err != nil {
@@ -108,3 +137,97 @@ func Reader(p string) (pp string, r any, err error) {
 	}
 	return p, file, nil
 }
+
+// splitArchive splits a path into a zip archive into the path of the archive and the name of the
+// entry in the archive, e.g. "dataset.zip/cldf/values.csv" into "dataset.zip" and
+// "cldf/values.csv". ok is false if p does not lead through an existing zip file.
+func splitArchive(p string) (archive string, name string, ok bool) {
+	for dir := filepath.Dir(p); ; dir = filepath.Dir(dir) {
+		if strings.HasSuffix(dir, ".zip") {
+			if info, err := os.Stat(dir); err == nil && info.Mode().IsRegular() {
+				rel, err := filepath.Rel(dir, p)
+				if err != nil {
+					return "", "", false
+				}
+				return dir, filepath.ToSlash(rel), true
+			}
+		}
+		if filepath.Dir(dir) == dir {
+			return "", "", false
+		}
+	}
+}
+
+// archiveFile looks up the entry with the given name in a zip archive.
+func archiveFile(archive string, name string) (*zip.File, error) {
+	r, err := zip.OpenReader(archive)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	for _, f := range r.File {
+		if f.Name == name {
+			return f, nil
+		}
+	}
+	return nil, fmt.Errorf("%v in %v: %w", name, archive, fs.ErrNotExist)
+}
+
+// openInArchive streams the entry with the given name from a zip archive, closing the archive
+// when closed.
+func openInArchive(archive string, name string) (io.ReadCloser, error) {
+	r, err := zip.OpenReader(archive)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range r.File {
+		if f.Name == name {
+			rc, err := f.Open()
+			if err != nil {
+				return nil, errors.Join(err, r.Close())
+			}
+			return &zipReader{rc, r}, nil
+		}
+	}
+	return nil, errors.Join(r.Close(), fmt.Errorf("%v in %v: %w", name, archive, fs.ErrNotExist))
+}
+
+// Glob returns the names of the files matching pattern like filepath.Glob, supporting patterns
+// for files in zip archives, e.g. "dataset.zip/*-metadata.json".
+func Glob(pattern string) ([]string, error) {
+	archive, namePattern, ok := splitArchive(pattern)
+	if !ok {
+		return filepath.Glob(pattern)
+	}
+	r, err := zip.OpenReader(archive)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	var res []string
+	for _, f := range r.File {
+		if matched, _ := path.Match(namePattern, f.Name); matched && !f.FileInfo().IsDir() {
+			res = append(res, filepath.Join(archive, filepath.FromSlash(f.Name)))
+		}
+	}
+	return res, nil
+}
+
+// ArchiveRoot returns the name of the directory in a zip archive which contains all entries, if
+// there is one, e.g. "dataset" for an archive of the directory dataset.
+func ArchiveRoot(archive string) (string, bool) {
+	r, err := zip.OpenReader(archive)
+	if err != nil {
+		return "", false
+	}
+	defer r.Close()
+	root := ""
+	for _, f := range r.File {
+		dir, _, found := strings.Cut(f.Name, "/")
+		if !found || (root != "" && dir != root) {
+			return "", false
+		}
+		root = dir
+	}
+	return root, root != ""
+}
@@ -1,7 +1,10 @@
 package pathutil
 
 import (
+	"archive/zip"
+	"errors"
 	"io"
+	"os"
 	"path/filepath"
 	"testing"
 )
@@ -40,3 +43,45 @@ func Test_Reader(t *testing.T) {
 		t.Errorf(`problem: "%v" vs "%v"`, expected, res)
 	}
 }
+
+func Test_archive(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "test.zip")
+	f, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := zip.NewWriter(f)
+	zf, err := w.Create("dir/test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = zf.Write([]byte("hello world!\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err = errors.Join(w.Close(), f.Close()); err != nil {
+		t.Fatal(err)
+	}
+
+	p := filepath.Join(archive, "dir", "test.txt")
+	if !PathExists(p) || !PathExists(filepath.Join(archive, "dir")) || PathExists(filepath.Join(archive, "test.txt")) {
+		t.Errorf(`problem: %v`, p)
+	}
+	_, r, err := Reader(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(r.(io.Reader))
+	if err != nil || string(b) != "hello world!\n" {
+		t.Errorf(`problem: %q %v`, b, err)
+	}
+	r.(io.Closer).Close()
+	if matches, err := Glob(filepath.Join(archive, "dir", "*.txt")); err != nil || len(matches) != 1 || matches[0] != p {
+		t.Errorf(`problem: %v %v`, matches, err)
+	}
+	if root, ok := ArchiveRoot(archive); !ok || root != "dir" {
+		t.Errorf(`problem: %v`, root)
+	}
+	if size, err := GetFormattedSize(p); err != nil || size != "13.0bytes" {
+		t.Errorf(`problem: %v %v`, size, err)
+	}
+}